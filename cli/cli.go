@@ -1,36 +1,111 @@
 package cli
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
 
 	"github.com/dulo-tech/service-thumbnails/cli/commands"
 	"github.com/dulo-tech/service-thumbnails/core"
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
 )
 
-func Go() {
-	router := commands.NewRouter(splitFiles(core.Opts.InFile), core.Opts.OutFile)
+// Go runs the command line app, and returns the exit code of the process.
+func Go() int {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	files, err := splitFiles(core.Opts.InFile)
+	if err != nil {
+		core.VErrorf("%s", err)
+		return 1
+	}
+
+	router := commands.NewRouter(files, core.Opts.OutFile)
 	router.Command("simple", commands.NewSimple())
 	router.Command("sprite", commands.NewSprite())
-	err := router.Route(core.Opts.ThumbType)
+	router.Command("vtt", commands.NewVTT())
+	router.Command("animated", commands.NewAnimated())
+	router.Command("preview", commands.NewPreview())
+	router.Command("frames", commands.NewFrames())
+	router.Command("waveform", commands.NewWaveform())
+	router.Command("contactsheet", commands.NewContactSheet())
+	router.Command("fingerprint", commands.NewFingerprint())
+	router.Command("compare", commands.NewCompare())
+	err = router.Route(ctx, core.Opts.ThumbType)
 	if err != nil {
-		panic(err)
+		printError(err)
+		return 1
 	}
+
+	return 0
 }
 
-// splitFiles converts a comma separated list of files into an array of file names.
-func splitFiles(inFiles string) []string {
-	files := strings.Split(inFiles, ",")
-	for i, f := range files {
-		files[i] = strings.Trim(f, " ")
+// printError prints the given error to stderr.
+// The command line and the tail of stderr are included for ffmpeg errors.
+func printError(err error) {
+	core.VErrorf("Error: %s", err)
+
+	var e *ffmpeg.Error
+	if errors.As(err, &e) {
+		core.VErrorf("Command: %s", e.CommandLine())
+		if e.Stderr != "" {
+			core.VErrorf("%s", e.Stderr)
+		}
 	}
+}
 
-	for _, file := range files {
+// splitFiles converts a comma separated list of files into an array of file names.
+// Directories are replaced by the files in them, skipping hidden files and
+// sub-directories. An error is returned when a file does not exist, or when no
+// files are found.
+func splitFiles(inFiles string) ([]string, error) {
+	files := []string{}
+	for _, file := range strings.Split(inFiles, ",") {
+		file = strings.Trim(file, " ")
 		if !core.FileExists(file) {
-			core.VErrorf("The input file %q does not exist.", file)
-			os.Exit(1)
+			return nil, fmt.Errorf("The input file %q does not exist.", file)
+		}
+
+		dir, err := dirFiles(file)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read the input directory %q: %s", file, err)
+		}
+		if dir == nil {
+			files = append(files, file)
 		}
+		files = append(files, dir...)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("No input files found in %q.", inFiles)
 	}
 
-	return files
+	return files, nil
+}
+
+// dirFiles returns the files in 'dir' in name order, or nil when 'dir' is
+// not a directory.
+func dirFiles(dir string) ([]string, error) {
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return nil, err
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+	return files, nil
 }
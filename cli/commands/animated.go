@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/dulo-tech/service-thumbnails/core"
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+)
+
+// DefaultAnimatedWidth is the width of animated thumbnails when the width option is 0.
+const DefaultAnimatedWidth = 320
+
+// AnimatedCommand is used to generate animated thumbnails from the command line.
+type AnimatedCommand struct {
+	Command
+}
+
+// NewAnimated creates and returns a new AnimatedCommand instance.
+func NewAnimated() *AnimatedCommand {
+	return &AnimatedCommand{
+		Command: *newCommand(),
+	}
+}
+
+// Execute processes a command instruction.
+func (c *AnimatedCommand) Execute(ctx context.Context, inFile, outFile string) {
+	defer func() {
+		(*c.chanFinished) <- true
+	}()
+
+	format, err := outputFormat(outFile)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+	skip, err := skipTimestamp()
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	opts := ffmpeg.DefaultOptions()
+	opts.Skip = skip
+	opts.Format = format
+	opts.Quality = core.Opts.Quality
+	opts.Segments = core.Opts.Segments
+	opts.SegmentLength = core.Opts.SegmentLen
+	opts.FPS = core.Opts.FPS
+	opts.Palette = core.Opts.Palette
+
+	f, err := newThumbnailer(inFile, opts)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	width := DefaultAnimatedWidth
+	if core.Opts.Width != 0 {
+		width = core.Opts.Width
+	}
+
+	err = f.CreateAnimated(ctx, width, outFile)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	core.VPrintf("Animated thumbnail for video %q written to %q.", inFile, outFile)
+}
@@ -1,12 +1,26 @@
 package commands
 
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/dulo-tech/service-thumbnails/core"
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+	"github.com/dulo-tech/service-thumbnails/palette"
+	"github.com/dulo-tech/service-thumbnails/placeholder"
+)
+
 type ChannelFinished chan bool
 type ChannelError chan error
 
 // Commander is an interface for types which execute command line instructions.
 type Commander interface {
 	SetChannels(*ChannelFinished, *ChannelError)
-	Execute(string, string)
+	Execute(context.Context, string, string)
 }
 
 // Command is used to create thumbs from the command line.
@@ -25,3 +39,129 @@ func (c *Command) SetChannels(f *ChannelFinished, e *ChannelError) {
 	c.chanFinished = f
 	c.chanError = e
 }
+
+// outputFormat returns the image format thumbnails are written in.
+// The extension of 'outFile' is used when the format option is not set.
+func outputFormat(outFile string) (ffmpeg.Format, error) {
+	if core.Opts.Format == "" {
+		return ffmpeg.FormatFromFile(outFile), nil
+	}
+	return ffmpeg.ParseFormat(core.Opts.Format)
+}
+
+// skipTimestamp returns the position in the video chosen by the skip option.
+func skipTimestamp() (ffmpeg.Timestamp, error) {
+	return ffmpeg.ParseTimestamp(core.Opts.SkipSeconds)
+}
+
+// placeholderOptions returns the placeholders and number of BlurHash
+// components chosen by the placeholder and components options. An error is
+// returned when placeholders are requested for thumbnails in 'format', which
+// cannot be decoded.
+func placeholderOptions(format ffmpeg.Format) ([]string, int, int, error) {
+	names, err := placeholder.Parse(core.Opts.Placeholder)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	x, y, err := placeholder.ParseComponents(core.Opts.Components)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if len(names) > 0 && !format.Decodable() {
+		return nil, 0, 0, fmt.Errorf("Placeholders cannot be created from %s thumbnails. Use jpeg, png or gif.", format)
+	}
+
+	return names, x, y, nil
+}
+
+// paletteOptions returns an error when the colors option is out of range,
+// or when the colors would be found in a thumbnail in 'format', which cannot
+// be decoded.
+func paletteOptions(format ffmpeg.Format) error {
+	if core.Opts.Colors < 0 || core.Opts.Colors > palette.MaxColors {
+		return fmt.Errorf("Invalid number of colors %d. Use 0 to %d.", core.Opts.Colors, palette.MaxColors)
+	}
+	if core.Opts.Colors > 0 && core.Opts.ColorFrames == 0 && !format.Decodable() {
+		return fmt.Errorf("Colors cannot be found in %s thumbnails. Use jpeg, png or gif, or set -colorframes.", format)
+	}
+
+	return nil
+}
+
+// thumbnailPalette returns the dominant colors of the thumbnail 'thumb', or
+// of frames of 'inFile' starting at 'skip' when the colorframes option is set.
+func thumbnailPalette(ctx context.Context, inFile, thumb string, skip ffmpeg.Timestamp) ([]palette.Color, error) {
+	if core.Opts.ColorFrames <= 0 {
+		return palette.FromFiles([]string{thumb}, core.Opts.Colors)
+	}
+
+	opts := ffmpeg.DefaultOptions()
+	opts.Skip = skip
+	opts.Workers = core.Opts.Workers
+	f, err := newThumbnailer(inFile, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return palette.FromVideo(ctx, f, core.Opts.ColorFrames, core.Opts.Colors)
+}
+
+// sidecarFile returns the name of the JSON file written next to 'outFile',
+// which is the same name with the .json extension.
+func sidecarFile(outFile string) string {
+	return strings.TrimSuffix(outFile, filepath.Ext(outFile)) + ".json"
+}
+
+// writeJSON writes 'v' to 'file' as indented JSON.
+func writeJSON(file string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, append(data, '\n'), 0644)
+}
+
+// overlayOptions adds the label and logo options to 'opts'.
+func overlayOptions(opts *ffmpeg.Options) error {
+	labelPos, err := ffmpeg.ParsePosition(core.Opts.LabelPos)
+	if err != nil {
+		return err
+	}
+	logoPos, err := ffmpeg.ParsePosition(core.Opts.LogoPos)
+	if err != nil {
+		return err
+	}
+	if err := ffmpeg.CheckLabelSize(core.Opts.LabelSize); err != nil {
+		return err
+	}
+	if err := ffmpeg.CheckLogoMargin(core.Opts.LogoMargin); err != nil {
+		return err
+	}
+
+	opts.Label = core.Opts.Label
+	opts.LabelFont = core.Opts.LabelFont
+	opts.LabelSize = core.Opts.LabelSize
+	opts.LabelColor = core.Opts.LabelColor
+	opts.LabelBox = core.Opts.LabelBox
+	opts.LabelPosition = labelPos
+	opts.Logo = core.Opts.Logo
+	opts.LogoPosition = logoPos
+	opts.LogoMargin = core.Opts.LogoMargin
+	opts.LogoOpacity = core.Opts.LogoOpacity
+
+	return nil
+}
+
+// newThumbnailer creates a VideoThumbnailer for the given video using the
+// backend option. The timeout option and verbose output are added to 'opts'.
+// An error is returned when the width or height option is out of range.
+func newThumbnailer(inFile string, opts ffmpeg.Options) (ffmpeg.VideoThumbnailer, error) {
+	if err := ffmpeg.CheckSize(core.Opts.Width, core.Opts.Height); err != nil {
+		return nil, err
+	}
+	opts.Timeout = core.Opts.TimeoutDuration()
+	opts.Logf = core.VPrintf
+
+	return ffmpeg.Open(core.Opts.Backend, inFile, opts)
+}
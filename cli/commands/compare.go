@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/dulo-tech/service-thumbnails/core"
+	"github.com/dulo-tech/service-thumbnails/phash"
+)
+
+// CompareCommand is used to find near-duplicate videos from the command line.
+type CompareCommand struct {
+	Command
+	mutex        sync.Mutex
+	fingerprints map[string]phash.Fingerprint
+}
+
+// Comparison is the distance between the fingerprints of two videos.
+type Comparison struct {
+	A        string  `json:"a"`
+	B        string  `json:"b"`
+	Distance float64 `json:"distance"`
+	// Duplicate is true when the distance is no more than the distance option.
+	Duplicate bool `json:"duplicate"`
+}
+
+// NewCompare creates and returns a new CompareCommand instance.
+func NewCompare() *CompareCommand {
+	return &CompareCommand{
+		Command:      *newCommand(),
+		fingerprints: make(map[string]phash.Fingerprint),
+	}
+}
+
+// Execute processes a command instruction.
+// The fingerprint of inFile is kept until Finish compares it with the others.
+func (c *CompareCommand) Execute(ctx context.Context, inFile, outFile string) {
+	defer func() {
+		(*c.chanFinished) <- true
+	}()
+
+	fp, err := videoFingerprint(ctx, inFile)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	c.mutex.Lock()
+	c.fingerprints[inFile] = fp
+	c.mutex.Unlock()
+}
+
+// Finish implements Finisher.Finish.
+// Every pair of videos is compared, the near-duplicates are printed, and the
+// comparisons are written to outFile as JSON, closest first. The distance is
+// printed for a single pair whether or not they're near-duplicates. Pairs
+// which cannot be compared, such as a short clip with fewer frames than the
+// others, are skipped.
+func (c *CompareCommand) Finish(ctx context.Context, outFile string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(c.fingerprints) < 2 {
+		return fmt.Errorf("At least two videos are needed to compare, got %d.", len(c.fingerprints))
+	}
+	files := make([]string, 0, len(c.fingerprints))
+	for file := range c.fingerprints {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	comparisons := []Comparison{}
+	for i, a := range files {
+		for _, b := range files[i+1:] {
+			d, err := c.fingerprints[a].Distance(c.fingerprints[b])
+			if err != nil {
+				core.VPrintf("Skipping videos %q and %q: %s", a, b, err)
+				continue
+			}
+			comparisons = append(comparisons, Comparison{
+				A:         a,
+				B:         b,
+				Distance:  d,
+				Duplicate: d <= float64(core.Opts.MaxDistance),
+			})
+		}
+	}
+	sort.SliceStable(comparisons, func(i, j int) bool {
+		return comparisons[i].Distance < comparisons[j].Distance
+	})
+
+	duplicates := 0
+	for _, cmp := range comparisons {
+		if cmp.Duplicate {
+			duplicates++
+			core.VPrintf("Videos %q and %q are near-duplicates with distance %.2f.", cmp.A, cmp.B, cmp.Distance)
+		} else if len(comparisons) == 1 {
+			core.VPrintf("Videos %q and %q are different with distance %.2f.", cmp.A, cmp.B, cmp.Distance)
+		}
+	}
+	core.VPrintf("Found %d near-duplicate pair(s) among %d videos.", duplicates, len(files))
+
+	if err := writeJSON(outFile, comparisons); err != nil {
+		return err
+	}
+	core.VPrintf("Comparisons written to %q.", outFile)
+
+	return nil
+}
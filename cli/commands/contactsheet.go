@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/dulo-tech/service-thumbnails/core"
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+)
+
+// ContactSheetCommand is used to generate contact sheets from the command line.
+type ContactSheetCommand struct {
+	Command
+}
+
+// NewContactSheet creates and returns a new ContactSheetCommand instance.
+func NewContactSheet() *ContactSheetCommand {
+	return &ContactSheetCommand{
+		Command: *newCommand(),
+	}
+}
+
+// Execute processes a command instruction.
+func (c *ContactSheetCommand) Execute(ctx context.Context, inFile, outFile string) {
+	defer func() {
+		(*c.chanFinished) <- true
+	}()
+
+	format, err := outputFormat(outFile)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+	skip, err := skipTimestamp()
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+	if err := ffmpeg.CheckSheetMargin(core.Opts.Margin); err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	opts := ffmpeg.DefaultOptions()
+	opts.Skip = skip
+	opts.Background = core.Opts.Background
+	opts.Format = format
+	opts.Quality = core.Opts.Quality
+	opts.Height = core.Opts.Height
+	opts.Fit = core.Opts.Fit
+	opts.PadColor = core.Opts.PadColor
+	opts.Workers = core.Opts.Workers
+	opts.SheetColumns = core.Opts.Columns
+	opts.SheetMargin = core.Opts.Margin
+	if err := overlayOptions(&opts); err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	f, err := newThumbnailer(inFile, opts)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	err = f.CreateContactSheet(ctx, core.Opts.Count, core.Opts.Width, outFile)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	core.VPrintf("Contact sheet for video %q written to %q.", inFile, outFile)
+}
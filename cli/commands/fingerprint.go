@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/dulo-tech/service-thumbnails/core"
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+	"github.com/dulo-tech/service-thumbnails/phash"
+)
+
+// FingerprintCommand is used to write the fingerprints of videos from the
+// command line.
+type FingerprintCommand struct {
+	Command
+}
+
+// NewFingerprint creates and returns a new FingerprintCommand instance.
+func NewFingerprint() *FingerprintCommand {
+	return &FingerprintCommand{
+		Command: *newCommand(),
+	}
+}
+
+// Execute processes a command instruction.
+// The fingerprint is written to outFile as JSON.
+func (c *FingerprintCommand) Execute(ctx context.Context, inFile, outFile string) {
+	defer func() {
+		(*c.chanFinished) <- true
+	}()
+
+	fp, err := videoFingerprint(ctx, inFile)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+	if err := writeJSON(outFile, fp); err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	core.VPrintf("Fingerprint of video %q written to %q.", inFile, outFile)
+}
+
+// videoFingerprint returns the fingerprint of 'inFile' using the hash and
+// hashframes options. The frames are spread through the whole video, whatever
+// the skip option.
+func videoFingerprint(ctx context.Context, inFile string) (phash.Fingerprint, error) {
+	algorithm, err := phash.ParseAlgorithm(core.Opts.Hash)
+	if err != nil {
+		return phash.Fingerprint{}, err
+	}
+	opts := ffmpeg.DefaultOptions()
+	opts.Workers = core.Opts.Workers
+	f, err := newThumbnailer(inFile, opts)
+	if err != nil {
+		return phash.Fingerprint{}, err
+	}
+
+	return phash.FromVideo(ctx, f, core.Opts.HashFrames, algorithm)
+}
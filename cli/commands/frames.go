@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/dulo-tech/service-thumbnails/core"
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+)
+
+// FramesCommand is used to generate several stills from the command line.
+type FramesCommand struct {
+	Command
+}
+
+// NewFrames creates and returns a new FramesCommand instance.
+func NewFrames() *FramesCommand {
+	return &FramesCommand{
+		Command: *newCommand(),
+	}
+}
+
+// Execute processes a command instruction.
+// The stills are taken at the positions given by the times option, or evenly
+// spaced through the video when it's empty, and written to separate files
+// using 'outFile' as a template.
+func (c *FramesCommand) Execute(ctx context.Context, inFile, outFile string) {
+	defer func() {
+		(*c.chanFinished) <- true
+	}()
+
+	format, err := outputFormat(outFile)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+	skip, err := skipTimestamp()
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	opts := ffmpeg.DefaultOptions()
+	opts.Skip = skip
+	opts.Format = format
+	opts.Quality = core.Opts.Quality
+	opts.Height = core.Opts.Height
+	opts.Fit = core.Opts.Fit
+	opts.PadColor = core.Opts.PadColor
+
+	f, err := newThumbnailer(inFile, opts)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	var times []ffmpeg.Timestamp
+	if core.Opts.Times != "" {
+		times, err = ffmpeg.ParseTimestamps(core.Opts.Times)
+	} else {
+		times, err = f.FrameTimes(ctx, core.Opts.Frames)
+	}
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	stills, err := f.CreateFrames(ctx, times, core.Opts.Width, outFile)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	for _, still := range stills {
+		core.VPrintf("Frame at %s of video %q written to %q.", ffmpeg.FormatTime(still.Time), inFile, still.File)
+	}
+}
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/dulo-tech/service-thumbnails/core"
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+)
+
+// DefaultPreviewWidth is the width of preview clips when the width option is 0.
+const DefaultPreviewWidth = 480
+
+// PreviewCommand is used to generate MP4 preview clips from the command line.
+type PreviewCommand struct {
+	Command
+}
+
+// NewPreview creates and returns a new PreviewCommand instance.
+func NewPreview() *PreviewCommand {
+	return &PreviewCommand{
+		Command: *newCommand(),
+	}
+}
+
+// Execute processes a command instruction.
+func (c *PreviewCommand) Execute(ctx context.Context, inFile, outFile string) {
+	defer func() {
+		(*c.chanFinished) <- true
+	}()
+
+	skip, err := skipTimestamp()
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	opts := ffmpeg.DefaultOptions()
+	opts.Skip = skip
+	opts.Segments = core.Opts.Segments
+	opts.SegmentLength = core.Opts.SegmentLen
+	opts.CRF = core.Opts.CRF
+
+	f, err := newThumbnailer(inFile, opts)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	width := DefaultPreviewWidth
+	if core.Opts.Width != 0 {
+		width = core.Opts.Width
+	}
+
+	err = f.CreatePreview(ctx, width, outFile)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	core.VPrintf("Preview clip for video %q written to %q.", inFile, outFile)
+}
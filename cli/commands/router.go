@@ -1,21 +1,32 @@
 package commands
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/dulo-tech/service-thumbnails/core"
 	"path/filepath"
+	"runtime"
 	"strings"
 )
 
 // Commanders is a map of Commander instances.
 type Commanders map[string]Commander
 
+// Finisher is implemented by commands which act on all of the in files
+// together. Finish is called once every file has been executed, with the out
+// file given to the router.
+type Finisher interface {
+	Finish(context.Context, string) error
+}
+
 // Router is used to dispatch command line instructions to executors.
 type Router struct {
 	coms    Commanders
 	inFiles []string
 	outFile string
+	// workers is the number of files executed at the same time.
+	workers int
 }
 
 // NewRouter creates and returns a new Router instance.
@@ -24,6 +35,7 @@ func NewRouter(inFiles []string, outFile string) *Router {
 		coms:    make(Commanders),
 		inFiles: inFiles,
 		outFile: outFile,
+		workers: runtime.GOMAXPROCS(0),
 	}
 }
 
@@ -32,41 +44,73 @@ func (r *Router) Command(ins string, exec Commander) {
 	r.coms[ins] = exec
 }
 
-// Execute executes the given instruction for the given in and out files.
-func (r *Router) Route(ins string) error {
+// Route executes the given instruction for the given in and out files.
+// At most GOMAXPROCS files are executed at the same time. The commands are
+// cancelled when ctx is done, or when one of them fails, and Route waits for
+// all of them to return before returning the first error.
+// Commands which implement Finisher are finished after every file has been
+// executed without an error. They write a single out file, so it may only use
+// {type} and not {name} or %d.
+func (r *Router) Route(ctx context.Context, ins string) error {
 	cmd, ok := r.coms[ins]
 	if !ok {
 		return errors.New("No command executor for instruction " + ins)
 	}
+	finisher, finish := cmd.(Finisher)
+	if finish && (strings.Contains(r.outFile, "%") || strings.Contains(r.outFile, "{name}")) {
+		return fmt.Errorf("The out file %q cannot use %%d or {name} with %s, which writes a single file.", r.outFile, ins)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	cf := make(ChannelFinished)
 	ce := make(ChannelError)
 	cmd.SetChannels(&cf, &ce)
 
-	core.VPrintf("Generating %d thumbnail(s).", len(r.inFiles))
-	for i, fin := range r.inFiles {
+	next, running := 0, 0
+	start := func() {
+		fin := r.inFiles[next]
 		base := strings.TrimSuffix(fin, filepath.Ext(fin))
-		fout := expandFileName(r.outFile, base, ins, i)
-		go cmd.Execute(fin, fout)
+		fout := expandFileName(r.outFile, base, ins, next)
+		go cmd.Execute(ctx, fin, fout)
+		next++
+		running++
 	}
 
-	running := len(r.inFiles)
-	for {
+	core.VPrintf("Generating %d thumbnail(s).", len(r.inFiles))
+	for running < r.workers && next < len(r.inFiles) {
+		start()
+	}
+
+	var err error
+	for running > 0 {
 		select {
-		case err := <-ce:
+		case e := <-ce:
 			{
-				return err
+				if err == nil {
+					err = e
+					cancel()
+				}
 			}
 		case <-cf:
 			{
 				running--
-				if running == 0 {
-					return nil
+				if err == nil && ctx.Err() == nil && next < len(r.inFiles) {
+					start()
 				}
 			}
 		}
 	}
+	if err == nil && next < len(r.inFiles) {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return err
+	}
 
+	if finish {
+		return finisher.Finish(ctx, expandFileName(r.outFile, "", ins, 0))
+	}
 	return nil
 }
 
@@ -0,0 +1,512 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dulo-tech/service-thumbnails/core"
+	"github.com/dulo-tech/service-thumbnails/fake"
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+	"github.com/dulo-tech/service-thumbnails/phash"
+	"github.com/dulo-tech/service-thumbnails/placeholder"
+)
+
+// recordingCommand is a Commander which records the files it's executed with.
+type recordingCommand struct {
+	Command
+	mutex sync.Mutex
+	calls []string
+	err   error
+}
+
+// Execute implements Commander.Execute.
+// Like the real commands, it always reports that it finished, even when it
+// fails.
+func (c *recordingCommand) Execute(ctx context.Context, inFile, outFile string) {
+	defer func() {
+		(*c.chanFinished) <- true
+	}()
+
+	c.mutex.Lock()
+	c.calls = append(c.calls, inFile+" > "+outFile)
+	c.mutex.Unlock()
+
+	if c.err != nil {
+		(*c.chanError) <- c.err
+	}
+}
+
+// blockingCommand is a Commander which fails for "fail.mp4", and waits to be
+// cancelled for every other file.
+type blockingCommand struct {
+	Command
+	cancelled chan string
+}
+
+// Execute implements Commander.Execute.
+func (c *blockingCommand) Execute(ctx context.Context, inFile, outFile string) {
+	defer func() {
+		(*c.chanFinished) <- true
+	}()
+
+	if inFile == "fail.mp4" {
+		(*c.chanError) <- errors.New("failed")
+		return
+	}
+	<-ctx.Done()
+	c.cancelled <- inFile
+}
+
+// countingCommand is a Commander which records the largest number of files it
+// was executing at the same time.
+type countingCommand struct {
+	Command
+	mutex    sync.Mutex
+	running  int
+	max      int
+	executed int
+}
+
+// Execute implements Commander.Execute.
+func (c *countingCommand) Execute(ctx context.Context, inFile, outFile string) {
+	defer func() {
+		(*c.chanFinished) <- true
+	}()
+
+	c.mutex.Lock()
+	c.running++
+	c.executed++
+	if c.running > c.max {
+		c.max = c.running
+	}
+	c.mutex.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	c.mutex.Lock()
+	c.running--
+	c.mutex.Unlock()
+}
+
+// withOptions restores the global options after a test changes them.
+func withOptions(t *testing.T) {
+	saved := *core.Opts
+	t.Cleanup(func() {
+		*core.Opts = saved
+	})
+	core.Opts.Quiet = true
+}
+
+// tempVideo creates an empty video file for the fake backend, and returns the
+// directory it was created in along with its path.
+func tempVideo(t *testing.T) (string, string) {
+	dir, err := ioutil.TempDir("", "thumb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	video := filepath.Join(dir, "video.mp4")
+	if err := ioutil.WriteFile(video, []byte("video"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir, video
+}
+
+// imageSize decodes the given image file, and returns its size.
+func imageSize(t *testing.T, file string) (int, int) {
+	fin, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fin.Close()
+
+	conf, _, err := image.DecodeConfig(fin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return conf.Width, conf.Height
+}
+
+func TestExpandFileName(t *testing.T) {
+	tests := []struct {
+		format string
+		name   string
+		typ    string
+		index  int
+		want   string
+	}{
+		{"thumb.jpg", "video", "simple", 0, "thumb.jpg"},
+		{"thumb%02d.jpg", "video", "simple", 3, "thumb03.jpg"},
+		{"{name}-{type}.jpg", "video", "sprite", 0, "video-sprite.jpg"},
+		{"{name}{index}.jpg", "video", "frames", 0, "video{index}.jpg"},
+	}
+
+	for _, test := range tests {
+		got := expandFileName(test.format, test.name, test.typ, test.index)
+		if got != test.want {
+			t.Errorf("expandFileName(%q) = %q, want %q", test.format, got, test.want)
+		}
+	}
+}
+
+func TestRouteUnknownInstruction(t *testing.T) {
+	router := NewRouter([]string{"video.mp4"}, "thumb.jpg")
+	router.Command("simple", &recordingCommand{})
+
+	err := router.Route(context.Background(), "sprite")
+	if err == nil || !strings.Contains(err.Error(), "sprite") {
+		t.Errorf("Route() error = %v, want an error naming the instruction", err)
+	}
+}
+
+func TestRouteExecutesEveryFile(t *testing.T) {
+	withOptions(t)
+	cmd := &recordingCommand{}
+	router := NewRouter([]string{"a.mp4", "b.mp4"}, "{name}-{type}%d.jpg")
+	router.Command("simple", cmd)
+
+	if err := router.Route(context.Background(), "simple"); err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+
+	sort.Strings(cmd.calls)
+	want := []string{"a.mp4 > a-simple0.jpg", "b.mp4 > b-simple1.jpg"}
+	if strings.Join(cmd.calls, ",") != strings.Join(want, ",") {
+		t.Errorf("Route() executed %v, want %v", cmd.calls, want)
+	}
+}
+
+func TestRouteReturnsCommandError(t *testing.T) {
+	withOptions(t)
+	want := errors.New("failed")
+	router := NewRouter([]string{"a.mp4"}, "thumb.jpg")
+	router.Command("simple", &recordingCommand{err: want})
+
+	if err := router.Route(context.Background(), "simple"); err != want {
+		t.Errorf("Route() error = %v, want %v", err, want)
+	}
+}
+
+func TestRouteCancelsAndWaitsAfterError(t *testing.T) {
+	withOptions(t)
+	cmd := &blockingCommand{cancelled: make(chan string, 2)}
+	router := NewRouter([]string{"a.mp4", "fail.mp4", "b.mp4"}, "thumb.jpg")
+	router.workers = 3
+	router.Command("simple", cmd)
+
+	if err := router.Route(context.Background(), "simple"); err == nil || err.Error() != "failed" {
+		t.Fatalf("Route() error = %v, want failed", err)
+	}
+	// Both of the other commands were cancelled before Route returned.
+	if len(cmd.cancelled) != 2 {
+		t.Errorf("%d commands were cancelled before Route returned, want 2", len(cmd.cancelled))
+	}
+}
+
+func TestRouteLimitsWorkers(t *testing.T) {
+	withOptions(t)
+	files := make([]string, 10)
+	for i := range files {
+		files[i] = fmt.Sprintf("video%d.mp4", i)
+	}
+
+	for _, workers := range []int{1, 3, 20} {
+		cmd := &countingCommand{}
+		router := NewRouter(files, "thumb%d.jpg")
+		router.workers = workers
+		router.Command("simple", cmd)
+
+		if err := router.Route(context.Background(), "simple"); err != nil {
+			t.Fatalf("Route() with %d workers error = %v", workers, err)
+		}
+		if cmd.executed != len(files) || cmd.max > workers {
+			t.Errorf("Route() with %d workers executed %d files, %d at once, want %d files", workers, cmd.executed, cmd.max, len(files))
+		}
+	}
+}
+
+func TestRouteStopsStartingFilesAfterError(t *testing.T) {
+	withOptions(t)
+	cmd := &recordingCommand{err: errors.New("failed")}
+	router := NewRouter([]string{"a.mp4", "b.mp4", "c.mp4"}, "thumb.jpg")
+	router.workers = 1
+	router.Command("simple", cmd)
+
+	if err := router.Route(context.Background(), "simple"); err == nil {
+		t.Fatal("Route() did not return an error")
+	}
+	if len(cmd.calls) != 1 {
+		t.Errorf("Route() executed %v after the first error, want a single file", cmd.calls)
+	}
+}
+
+// readJSON decodes the JSON file 'file' into v.
+func readJSON(t *testing.T, file string, v interface{}) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// routeFake routes the instruction 'ins' to 'cmd' for the given videos using
+// the fake backend.
+func routeFake(videos []string, out, ins string, cmd Commander) error {
+	core.Opts.Backend = fake.Name
+	router := NewRouter(videos, out)
+	router.Command(ins, cmd)
+	return router.Route(context.Background(), ins)
+}
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		ins   string
+		cmd   Commander
+		opts  func()
+		out   string
+		check func(t *testing.T, dir, out string)
+	}{
+		{
+			ins:  "simple",
+			cmd:  NewSimple(),
+			opts: func() { core.Opts.Width = 320 },
+			out:  "thumb.jpg",
+			check: func(t *testing.T, dir, out string) {
+				if w, h := imageSize(t, out); w != 320 || h != 180 {
+					t.Errorf("thumbnail size = %dx%d, want 320x180", w, h)
+				}
+			},
+		},
+		{
+			ins:  "simple",
+			cmd:  NewSimple(),
+			opts: func() { core.Opts.Placeholder = "blurhash,thumbhash" },
+			out:  "thumb.jpg",
+			check: func(t *testing.T, dir, out string) {
+				p := placeholder.Placeholders{}
+				readJSON(t, filepath.Join(dir, "thumb.json"), &p)
+				if len(p.BlurHash) != 28 || p.ThumbHash == "" {
+					t.Errorf("placeholders = %+v, want a 4x3 BlurHash and a ThumbHash", p)
+				}
+			},
+		},
+		{
+			ins: "simple",
+			cmd: NewSimple(),
+			opts: func() {
+				core.Opts.Colors = 4
+				core.Opts.ColorFrames = 3
+			},
+			out: "thumb.jpg",
+			check: func(t *testing.T, dir, out string) {
+				sidecar := simpleSidecar{}
+				readJSON(t, filepath.Join(dir, "thumb.json"), &sidecar)
+				if len(sidecar.Palette) == 0 || len(sidecar.Palette) > 4 || sidecar.BlurHash != "" {
+					t.Errorf("sidecar = %+v, want 1 to 4 colors and no placeholders", sidecar)
+				}
+			},
+		},
+		{
+			ins: "sprite",
+			cmd: NewSprite(),
+			opts: func() {
+				core.Opts.Width = 64
+				core.Opts.Count = 6
+				core.Opts.Layout = "3x"
+				core.Opts.Manifest = true
+			},
+			out: "sprite.jpg",
+			check: func(t *testing.T, dir, out string) {
+				manifest := ffmpeg.SpriteManifest{}
+				readJSON(t, filepath.Join(dir, "sprite.json"), &manifest)
+				if manifest.Image != "sprite.jpg" || len(manifest.Frames) != 6 {
+					t.Errorf("manifest = %q with %d frames, want sprite.jpg with 6 frames", manifest.Image, len(manifest.Frames))
+				}
+				if w, h := imageSize(t, out); w != manifest.Width || h != manifest.Height {
+					t.Errorf("sprite size = %dx%d, want the %dx%d of the manifest", w, h, manifest.Width, manifest.Height)
+				}
+			},
+		},
+		{
+			ins: "vtt",
+			cmd: NewVTT(),
+			opts: func() {
+				core.Opts.Width = 64
+				core.Opts.Count = 6
+			},
+			out: "sprite.jpg",
+			check: func(t *testing.T, dir, out string) {
+				data, err := ioutil.ReadFile(filepath.Join(dir, "sprite.vtt"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.HasPrefix(string(data), "WEBVTT\n") || strings.Count(string(data), "sprite.jpg#xywh=") != 6 {
+					t.Errorf("track = %q, want 6 cues for sprite.jpg", data)
+				}
+			},
+		},
+		{
+			ins:  "frames",
+			cmd:  NewFrames(),
+			opts: func() { core.Opts.Times = "10,50%,00:00:40" },
+			out:  "frame{index}.jpg",
+			check: func(t *testing.T, dir, out string) {
+				for _, name := range []string{"frame01.jpg", "frame02.jpg", "frame03.jpg"} {
+					if !core.FileExists(filepath.Join(dir, name)) {
+						t.Errorf("frame %q was not written", name)
+					}
+				}
+			},
+		},
+		{
+			ins:  "waveform",
+			cmd:  NewWaveform(),
+			opts: func() {},
+			out:  "wave.jpg",
+			check: func(t *testing.T, dir, out string) {
+				if w, h := imageSize(t, out); w != ffmpeg.DefaultWaveformWidth || h != ffmpeg.DefaultWaveformHeight {
+					t.Errorf("waveform size = %dx%d, want %dx%d", w, h, ffmpeg.DefaultWaveformWidth, ffmpeg.DefaultWaveformHeight)
+				}
+			},
+		},
+		{
+			ins: "contactsheet",
+			cmd: NewContactSheet(),
+			opts: func() {
+				core.Opts.Count = 4
+				core.Opts.Columns = 2
+				core.Opts.Width = 100
+			},
+			out: "sheet.png",
+			check: func(t *testing.T, dir, out string) {
+				opts := ffmpeg.DefaultOptions()
+				opts.SheetColumns = 2
+				_, bounds := opts.SheetLayout(make([]float64, 4), 100, 56, 3)
+				if w, h := imageSize(t, out); w != bounds.Dx() || h != bounds.Dy() {
+					t.Errorf("contact sheet size = %dx%d, want %dx%d", w, h, bounds.Dx(), bounds.Dy())
+				}
+			},
+		},
+		{
+			ins:  "fingerprint",
+			cmd:  NewFingerprint(),
+			opts: func() { core.Opts.HashFrames = 4 },
+			out:  "video.json",
+			check: func(t *testing.T, dir, out string) {
+				fp := phash.Fingerprint{}
+				readJSON(t, out, &fp)
+				if fp.Algorithm != phash.PHash || len(fp.Frames) != 4 {
+					t.Errorf("fingerprint = %s with %d frames, want phash with 4 frames", fp.Algorithm, len(fp.Frames))
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.ins, func(t *testing.T) {
+			withOptions(t)
+			test.opts()
+			dir, video := tempVideo(t)
+			out := filepath.Join(dir, test.out)
+
+			if err := routeFake([]string{video}, out, test.ins, test.cmd); err != nil {
+				t.Fatalf("Route() error = %v", err)
+			}
+			test.check(t, dir, out)
+		})
+	}
+}
+
+func TestCommandErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		ins  string
+		cmd  Commander
+		opts func()
+		want string
+	}{
+		{"invalid backend", "simple", NewSimple(), func() { core.Opts.Backend = "missing" }, "missing"},
+		{"invalid layout", "sprite", NewSprite(), func() { core.Opts.Layout = "round" }, "layout"},
+		{"negative padding", "sprite", NewSprite(), func() { core.Opts.Padding = -1 }, "padding"},
+		{"large padding", "vtt", NewVTT(), func() { core.Opts.Padding = ffmpeg.MaxPadding + 1 }, "padding"},
+		{"negative width", "simple", NewSimple(), func() { core.Opts.Width = -1 }, "width"},
+		{"large height", "sprite", NewSprite(), func() { core.Opts.Height = ffmpeg.MaxSize + 1 }, "height"},
+		{"large sprite", "sprite", NewSprite(), func() { core.Opts.Width, core.Opts.Height = 8000, 8000 }, "too large"},
+		{"large margin", "contactsheet", NewContactSheet(), func() { core.Opts.Margin = ffmpeg.MaxSheetMargin + 1 }, "margin"},
+		{"large label", "contactsheet", NewContactSheet(), func() { core.Opts.LabelSize = ffmpeg.MaxLabelSize + 1 }, "label size"},
+		{"large sheet", "contactsheet", NewContactSheet(), func() { core.Opts.Width, core.Opts.Height = 4000, 4000 }, "too large"},
+		{"large logo margin", "simple", NewSimple(), func() { core.Opts.LogoMargin = ffmpeg.MaxLogoMargin + 1 }, "logo margin"},
+		{"invalid hash", "fingerprint", NewFingerprint(), func() { core.Opts.Hash = "md5" }, "md5"},
+		{"single video", "compare", NewCompare(), func() {}, "two videos"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			withOptions(t)
+			dir, video := tempVideo(t)
+			core.Opts.Backend = fake.Name
+			test.opts()
+
+			router := NewRouter([]string{video}, filepath.Join(dir, "out.jpg"))
+			router.Command(test.ins, test.cmd)
+			err := router.Route(context.Background(), test.ins)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("Route() error = %v, want an error about %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestCompareCommand(t *testing.T) {
+	withOptions(t)
+	core.Opts.HashFrames = 4
+	dir, video := tempVideo(t)
+	copy := filepath.Join(dir, "copy.mp4")
+	if err := ioutil.WriteFile(copy, []byte("video"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "compare.json")
+
+	if err := routeFake([]string{video, copy}, out, "compare", NewCompare()); err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+
+	comparisons := []Comparison{}
+	readJSON(t, out, &comparisons)
+	// The fake backend draws the same frames for every video.
+	if len(comparisons) != 1 || comparisons[0].Distance != 0 || !comparisons[0].Duplicate {
+		t.Errorf("comparisons = %+v, want a single duplicate pair with distance 0", comparisons)
+	}
+
+	// Compare writes a single file, so only {type} is expanded.
+	os.Remove(out)
+	if err := routeFake([]string{video, copy}, filepath.Join(dir, "{type}.json"), "compare", NewCompare()); err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	readJSON(t, out, &comparisons)
+	for _, out := range []string{"{name}.json", "compare%d.json"} {
+		err := routeFake([]string{video, copy}, filepath.Join(dir, out), "compare", NewCompare())
+		if err == nil || !strings.Contains(err.Error(), "single file") {
+			t.Errorf("Route() with out file %q error = %v, want an error about a single file", out, err)
+		}
+	}
+}
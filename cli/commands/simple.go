@@ -1,8 +1,12 @@
 package commands
 
 import (
+	"context"
+
 	"github.com/dulo-tech/service-thumbnails/core"
 	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+	"github.com/dulo-tech/service-thumbnails/palette"
+	"github.com/dulo-tech/service-thumbnails/placeholder"
 )
 
 // SimpleCommand is used to generate simple thumbnails from the command line.
@@ -10,6 +14,13 @@ type SimpleCommand struct {
 	Command
 }
 
+// simpleSidecar is written as JSON next to simple thumbnails when
+// placeholders or colors are requested.
+type simpleSidecar struct {
+	placeholder.Placeholders
+	Palette []palette.Color `json:"palette,omitempty"`
+}
+
 // NewSimple creates and returns a new SimpleCommand instance.
 func NewSimple() *SimpleCommand {
 	return &SimpleCommand{
@@ -18,19 +29,105 @@ func NewSimple() *SimpleCommand {
 }
 
 // Execute processes a command instruction.
-func (c *SimpleCommand) Execute(inFile, outFile string) {
+func (c *SimpleCommand) Execute(ctx context.Context, inFile, outFile string) {
 	defer func() {
 		(*c.chanFinished) <- true
 	}()
 
-	f := ffmpeg.New(inFile)
-	f.SkipSeconds = core.Opts.SkipSeconds
+	format, err := outputFormat(outFile)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+	skip, err := skipTimestamp()
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+	placeholders, px, py, err := placeholderOptions(format)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+	if err := paletteOptions(format); err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	opts := ffmpeg.DefaultOptions()
+	opts.Skip = skip
+	opts.Format = format
+	opts.Quality = core.Opts.Quality
+	opts.Height = core.Opts.Height
+	opts.Fit = core.Opts.Fit
+	opts.PadColor = core.Opts.PadColor
+	opts.Candidates = core.Opts.Candidates
+	opts.SearchWindow = core.Opts.SearchWindow
+	opts.Workers = core.Opts.Workers
+	if err := overlayOptions(&opts); err != nil {
+		(*c.chanError) <- err
+		return
+	}
 
-	err := f.CreateThumbnail(core.Opts.Width, outFile)
+	f, err := newThumbnailer(inFile, opts)
 	if err != nil {
 		(*c.chanError) <- err
 		return
 	}
 
+	if core.Opts.Smart {
+		best, err := f.CreateBestThumbnail(ctx, core.Opts.Width, outFile)
+		if err != nil {
+			(*c.chanError) <- err
+			return
+		}
+		core.VPrintf(
+			"Chose frame at %s for video %q with score %.3f (brightness %.3f, contrast %.3f, sharpness %.3f, uniformity %.3f).",
+			ffmpeg.FormatTime(best.Time),
+			inFile,
+			best.Score.Total,
+			best.Score.Brightness,
+			best.Score.Contrast,
+			best.Score.Sharpness,
+			best.Score.Uniformity)
+	} else {
+		err := f.CreateThumbnail(ctx, core.Opts.Width, outFile)
+		if err != nil {
+			(*c.chanError) <- err
+			return
+		}
+	}
+
 	core.VPrintf("Simple thumbnail for video %q written to %q.", inFile, outFile)
+
+	if len(placeholders) == 0 && core.Opts.Colors == 0 {
+		return
+	}
+	sidecar := simpleSidecar{}
+	sidecar.Placeholders, err = placeholder.FromFile(outFile, placeholders, px, py)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+	if sidecar.BlurHash != "" {
+		core.VPrintf("BlurHash of thumbnail %q: %s", outFile, sidecar.BlurHash)
+	}
+	if sidecar.ThumbHash != "" {
+		core.VPrintf("ThumbHash of thumbnail %q: %s", outFile, sidecar.ThumbHash)
+	}
+	if core.Opts.Colors > 0 {
+		sidecar.Palette, err = thumbnailPalette(ctx, inFile, outFile, skip)
+		if err != nil {
+			(*c.chanError) <- err
+			return
+		}
+		core.VPrintf("Colors of thumbnail %q: %s", outFile, palette.String(sidecar.Palette))
+	}
+
+	jsonFile := sidecarFile(outFile)
+	if err := writeJSON(jsonFile, sidecar); err != nil {
+		(*c.chanError) <- err
+		return
+	}
+	core.VPrintf("Placeholders and colors of thumbnail %q written to %q.", outFile, jsonFile)
 }
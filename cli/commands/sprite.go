@@ -1,6 +1,9 @@
 package commands
 
 import (
+	"context"
+	"path/filepath"
+
 	"github.com/dulo-tech/service-thumbnails/core"
 	"github.com/dulo-tech/service-thumbnails/ffmpeg"
 )
@@ -18,32 +21,105 @@ func NewSprite() *SpriteCommand {
 }
 
 // Execute processes a command instruction.
-func (c *SpriteCommand) Execute(inFile, outFile string) {
+// When the manifest option is set, a JSON manifest of the sprite is written
+// next to outFile using the same name with a .json extension.
+func (c *SpriteCommand) Execute(ctx context.Context, inFile, outFile string) {
 	defer func() {
 		(*c.chanFinished) <- true
 	}()
 
-	f := ffmpeg.New(inFile)
-	f.SkipSeconds = core.Opts.SkipSeconds
-
-	len := int(f.Length())
-	interval := 0
-	if len < core.Opts.Count {
-		interval = len
-	} else {
-		interval = len / core.Opts.Count
+	f, err := newSpriteThumbnailer(inFile, outFile)
+	if err != nil {
+		(*c.chanError) <- err
+		return
 	}
 
-	width := 180
-	if core.Opts.Width != 0 {
-		width = core.Opts.Width
+	interval, err := f.SpriteInterval(ctx, core.Opts.Count)
+	if err != nil {
+		(*c.chanError) <- err
+		return
 	}
 
-	err := f.CreateThumbnailSprite(interval, width, outFile)
+	sprite, err := f.CreateThumbnailSprite(ctx, interval, spriteWidth(), outFile)
 	if err != nil {
 		(*c.chanError) <- err
 		return
 	}
 
 	core.VPrintf("Sprite thumbnail for video %q written to %q.", inFile, outFile)
+	if !core.Opts.Manifest {
+		return
+	}
+
+	format, err := outputFormat(outFile)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+	manifestFile := sidecarFile(outFile)
+	err = sprite.WriteManifest(manifestFile, filepath.Base(outFile), format)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	core.VPrintf("Sprite manifest written to %q.", manifestFile)
+}
+
+// newSpriteThumbnailer creates and returns a VideoThumbnailer for the given
+// video using the sprite options.
+func newSpriteThumbnailer(inFile, outFile string) (ffmpeg.VideoThumbnailer, error) {
+	layout, err := ffmpeg.ParseLayout(core.Opts.Layout)
+	if err != nil {
+		return nil, err
+	}
+	if err := ffmpeg.CheckPadding(core.Opts.Padding); err != nil {
+		return nil, err
+	}
+	selection, err := ffmpeg.ParseSelection(core.Opts.Selection)
+	if err != nil {
+		return nil, err
+	}
+	seek, err := ffmpeg.ParseSeek(core.Opts.Seek)
+	if err != nil {
+		return nil, err
+	}
+	format, err := outputFormat(outFile)
+	if err != nil {
+		return nil, err
+	}
+	skip, err := skipTimestamp()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := ffmpeg.DefaultOptions()
+	opts.Skip = skip
+	opts.Layout = layout
+	opts.Padding = core.Opts.Padding
+	opts.Background = core.Opts.Background
+	opts.Format = format
+	opts.Quality = core.Opts.Quality
+	opts.Height = core.Opts.Height
+	opts.Fit = core.Opts.Fit
+	opts.PadColor = core.Opts.PadColor
+	opts.Selection = selection
+	opts.SceneThreshold = core.Opts.Threshold
+	opts.Seek = seek
+	opts.Workers = core.Opts.Workers
+	if err := overlayOptions(&opts); err != nil {
+		return nil, err
+	}
+
+	return newThumbnailer(inFile, opts)
+}
+
+// spriteWidth returns the width of each frame in a sprite.
+func spriteWidth() int {
+	width := 180
+	if core.Opts.Width != 0 {
+		width = core.Opts.Width
+	}
+
+	return width
 }
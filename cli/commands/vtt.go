@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/dulo-tech/service-thumbnails/core"
+)
+
+// VTTCommand is used to generate sprite thumbnails along with a WebVTT
+// thumbnail track from the command line.
+type VTTCommand struct {
+	Command
+}
+
+// NewVTT creates and returns a new VTTCommand instance.
+func NewVTT() *VTTCommand {
+	return &VTTCommand{
+		Command: *newCommand(),
+	}
+}
+
+// Execute processes a command instruction.
+// The sprite is written to outFile, and the track is written next to it using
+// the same name with a .vtt extension.
+func (c *VTTCommand) Execute(ctx context.Context, inFile, outFile string) {
+	defer func() {
+		(*c.chanFinished) <- true
+	}()
+
+	f, err := newSpriteThumbnailer(inFile, outFile)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	interval, err := f.SpriteInterval(ctx, core.Opts.Count)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	vttFile := strings.TrimSuffix(outFile, filepath.Ext(outFile)) + ".vtt"
+	err = f.CreateThumbnailVTT(ctx, interval, spriteWidth(), outFile, vttFile, filepath.Base(outFile))
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	core.VPrintf("Sprite thumbnail for video %q written to %q with track %q.", inFile, outFile, vttFile)
+}
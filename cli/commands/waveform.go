@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/dulo-tech/service-thumbnails/core"
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+)
+
+// WaveformCommand is used to generate audio waveform images from the command line.
+type WaveformCommand struct {
+	Command
+}
+
+// NewWaveform creates and returns a new WaveformCommand instance.
+func NewWaveform() *WaveformCommand {
+	return &WaveformCommand{
+		Command: *newCommand(),
+	}
+}
+
+// Execute processes a command instruction.
+func (c *WaveformCommand) Execute(ctx context.Context, inFile, outFile string) {
+	defer func() {
+		(*c.chanFinished) <- true
+	}()
+
+	format, err := outputFormat(outFile)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	opts := ffmpeg.DefaultOptions()
+	opts.Format = format
+	opts.Quality = core.Opts.Quality
+	opts.Height = core.Opts.Height
+	opts.Fit = core.Opts.Fit
+	opts.PadColor = core.Opts.PadColor
+	opts.WaveColor = core.Opts.WaveColor
+	opts.WaveBackground = core.Opts.WaveBg
+	opts.CoverArt = core.Opts.CoverArt
+
+	f, err := newThumbnailer(inFile, opts)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	err = f.CreateWaveform(ctx, core.Opts.Width, outFile)
+	if err != nil {
+		(*c.chanError) <- err
+		return
+	}
+
+	core.VPrintf("Waveform for file %q written to %q.", inFile, outFile)
+}
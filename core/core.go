@@ -3,6 +3,7 @@ package core
 import (
 	"fmt"
 	"os"
+	"time"
 )
 
 const (
@@ -15,33 +16,127 @@ const (
 // Default values for command line options.
 const (
 	OptDefaultMode         = "cli"
+	OptDefaultBackend      = "ffmpeg"
 	OptDefaultHost         = "127.0.0.1"
 	OptDefaultPort         = 8080
 	OptDefaultThumbType    = "simple"
 	OptDefaultInFile       = ""
 	OptDefaultOutFile      = ""
 	OptDefaultWidth        = 0
-	OptDefaultSkipSeconds  = 0
+	OptDefaultHeight       = 0
+	OptDefaultFit          = "contain"
+	OptDefaultPadColor     = "black"
+	OptDefaultSkipSeconds  = "0"
 	OptDefaultCount        = ThumbCountPerSprite
+	OptDefaultLayout       = "strip"
+	OptDefaultPadding      = 0
+	OptDefaultBackground   = "black"
+	OptDefaultFormat       = ""
+	OptDefaultQuality      = 90
+	OptDefaultTimeout      = 0
+	OptDefaultSelection    = "interval"
+	OptDefaultThreshold    = 0.3
+	OptDefaultSeek         = "fast"
+	OptDefaultWorkers      = 4
+	OptDefaultSmart        = false
+	OptDefaultCandidates   = 8
+	OptDefaultSearchWindow = 0
+	OptDefaultSegments     = 5
+	OptDefaultSegmentLen   = 1.0
+	OptDefaultFPS          = 10
+	OptDefaultPalette      = true
+	OptDefaultCRF          = 28
+	OptDefaultTimes        = ""
+	OptDefaultFrames       = 5
+	OptDefaultWaveColor    = "white"
+	OptDefaultWaveBg       = "black"
+	OptDefaultCoverArt     = false
+	OptDefaultLabel        = ""
+	OptDefaultLabelFont    = ""
+	OptDefaultLabelSize    = 16
+	OptDefaultLabelColor   = "white"
+	OptDefaultLabelBox     = "black@0.5"
+	OptDefaultLabelPos     = "bottom-left"
+	OptDefaultLogo         = ""
+	OptDefaultLogoPos      = "bottom-right"
+	OptDefaultLogoMargin   = 10
+	OptDefaultLogoOpacity  = 1.0
+	OptDefaultColumns      = 5
+	OptDefaultMargin       = 10
+	OptDefaultManifest     = false
+	OptDefaultPlaceholder  = ""
+	OptDefaultComponents   = "4x3"
+	OptDefaultColors       = 0
+	OptDefaultColorFrames  = 0
+	OptDefaultHash         = "phash"
+	OptDefaultHashFrames   = 16
+	OptDefaultMaxDistance  = 10
 	OptDefaultQuiet        = false
 	OptDefaultPrintHelp    = false
 	OptDefaultPrintVersion = false
 )
 
 // ThumbTypes stores the possible thumbnail types that may be generated.
-var ValidThumbTypes = []string{"sprite", "simple"}
+var ValidThumbTypes = []string{"sprite", "simple", "vtt", "animated", "preview", "frames", "waveform", "contactsheet", "fingerprint", "compare"}
 
 // Options stores the command line options.
 type Options struct {
 	Mode         string
+	Backend      string
 	Host         string
 	Port         int
 	ThumbType    string
 	InFile       string
 	OutFile      string
 	Width        int
-	SkipSeconds  int
+	Height       int
+	Fit          string
+	PadColor     string
+	SkipSeconds  string
 	Count        int
+	Layout       string
+	Padding      int
+	Background   string
+	Format       string
+	Quality      int
+	Timeout      int
+	Selection    string
+	Threshold    float64
+	Seek         string
+	Workers      int
+	Smart        bool
+	Candidates   int
+	SearchWindow int
+	Segments     int
+	SegmentLen   float64
+	FPS          int
+	Palette      bool
+	CRF          int
+	Times        string
+	Frames       int
+	WaveColor    string
+	WaveBg       string
+	CoverArt     bool
+	Label        string
+	LabelFont    string
+	LabelSize    int
+	LabelColor   string
+	LabelBox     string
+	LabelPos     string
+	Logo         string
+	LogoPos      string
+	LogoMargin   int
+	LogoOpacity  float64
+	Columns      int
+	Margin       int
+	Manifest     bool
+	Placeholder  string
+	Components   string
+	Colors       int
+	ColorFrames  int
+	Hash         string
+	HashFrames   int
+	MaxDistance  int
 	Quiet        bool
 	PrintHelp    bool
 	PrintVersion bool
@@ -50,19 +145,71 @@ type Options struct {
 // opts stores the command line options.
 var Opts = &Options{
 	Mode:         OptDefaultMode,
+	Backend:      OptDefaultBackend,
 	Host:         OptDefaultHost,
 	Port:         OptDefaultPort,
 	ThumbType:    OptDefaultThumbType,
 	InFile:       OptDefaultInFile,
 	OutFile:      OptDefaultOutFile,
 	Width:        OptDefaultWidth,
+	Height:       OptDefaultHeight,
+	Fit:          OptDefaultFit,
+	PadColor:     OptDefaultPadColor,
 	SkipSeconds:  OptDefaultSkipSeconds,
 	Count:        OptDefaultCount,
+	Layout:       OptDefaultLayout,
+	Padding:      OptDefaultPadding,
+	Background:   OptDefaultBackground,
+	Format:       OptDefaultFormat,
+	Quality:      OptDefaultQuality,
+	Timeout:      OptDefaultTimeout,
+	Selection:    OptDefaultSelection,
+	Threshold:    OptDefaultThreshold,
+	Seek:         OptDefaultSeek,
+	Workers:      OptDefaultWorkers,
+	Smart:        OptDefaultSmart,
+	Candidates:   OptDefaultCandidates,
+	SearchWindow: OptDefaultSearchWindow,
+	Segments:     OptDefaultSegments,
+	SegmentLen:   OptDefaultSegmentLen,
+	FPS:          OptDefaultFPS,
+	Palette:      OptDefaultPalette,
+	CRF:          OptDefaultCRF,
+	Times:        OptDefaultTimes,
+	Frames:       OptDefaultFrames,
+	WaveColor:    OptDefaultWaveColor,
+	WaveBg:       OptDefaultWaveBg,
+	CoverArt:     OptDefaultCoverArt,
+	Label:        OptDefaultLabel,
+	LabelFont:    OptDefaultLabelFont,
+	LabelSize:    OptDefaultLabelSize,
+	LabelColor:   OptDefaultLabelColor,
+	LabelBox:     OptDefaultLabelBox,
+	LabelPos:     OptDefaultLabelPos,
+	Logo:         OptDefaultLogo,
+	LogoPos:      OptDefaultLogoPos,
+	LogoMargin:   OptDefaultLogoMargin,
+	LogoOpacity:  OptDefaultLogoOpacity,
+	Columns:      OptDefaultColumns,
+	Margin:       OptDefaultMargin,
+	Manifest:     OptDefaultManifest,
+	Placeholder:  OptDefaultPlaceholder,
+	Components:   OptDefaultComponents,
+	Colors:       OptDefaultColors,
+	ColorFrames:  OptDefaultColorFrames,
+	Hash:         OptDefaultHash,
+	HashFrames:   OptDefaultHashFrames,
+	MaxDistance:  OptDefaultMaxDistance,
 	Quiet:        OptDefaultQuiet,
 	PrintHelp:    OptDefaultPrintHelp,
 	PrintVersion: OptDefaultPrintVersion,
 }
 
+// TimeoutDuration returns the Timeout option as a time.Duration.
+func (o *Options) TimeoutDuration() time.Duration {
+	return time.Duration(o.Timeout) * time.Second
+}
+
 // BuildInfo returns a string with the build information.
 func BuildInfo() string {
 	return fmt.Sprintf(
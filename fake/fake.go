@@ -0,0 +1,464 @@
+// Package fake provides a VideoThumbnailer backend which creates synthetic
+// thumbnails in process, without running ffmpeg.
+//
+// The backend never decodes the video. Frames are drawn from their position in
+// the video, so the same options always produce the same images, which makes
+// the backend useful for tests. Labels and logos are not drawn. Importing the
+// package registers the backend with the name "fake".
+package fake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+)
+
+// Name is the name the backend is registered with.
+const Name = "fake"
+
+// Metadata of the synthetic video.
+const (
+	DefaultDuration  = 60.0
+	DefaultWidth     = 640
+	DefaultHeight    = 360
+	DefaultFrameRate = 25.0
+)
+
+func init() {
+	ffmpeg.Register(Name, func(video string, opts ffmpeg.Options) ffmpeg.VideoThumbnailer {
+		return New(video, opts)
+	})
+}
+
+// Fake is a VideoThumbnailer which creates synthetic thumbnails.
+type Fake struct {
+	ffmpeg.Options
+	Video string
+	// Metadata is returned by Probe. The duration, dimensions and frame rate
+	// are used to size and place the synthetic frames.
+	Metadata ffmpeg.Metadata
+}
+
+// Fake must implement VideoThumbnailer.
+var _ ffmpeg.VideoThumbnailer = (*Fake)(nil)
+
+// New creates and returns a new Fake instance with the default metadata.
+func New(video string, opts ffmpeg.Options) *Fake {
+	return &Fake{
+		Options: opts,
+		Video:   video,
+		Metadata: ffmpeg.Metadata{
+			Duration:           DefaultDuration,
+			Width:              DefaultWidth,
+			Height:             DefaultHeight,
+			SampleAspectRatio:  "1:1",
+			DisplayAspectRatio: "16:9",
+			FrameRate:          DefaultFrameRate,
+			VideoCodec:         "h264",
+			Format:             "mov,mp4,m4a,3gp,3g2,mj2",
+			FormatLongName:     "QuickTime / MOV",
+		},
+	}
+}
+
+// Probe returns Fake.Metadata. An error is returned when the video file does
+// not exist.
+func (f *Fake) Probe(ctx context.Context) (*ffmpeg.Metadata, error) {
+	info, err := os.Stat(f.Video)
+	if err != nil {
+		return nil, err
+	}
+
+	m := f.Metadata
+	m.Size = info.Size()
+	return &m, nil
+}
+
+// Length returns the length of the video in seconds.
+func (f *Fake) Length(ctx context.Context) (float64, error) {
+	m, err := f.Probe(ctx)
+	if err != nil {
+		return 0.0, err
+	}
+
+	return m.Duration, nil
+}
+
+// SpriteInterval returns the number of seconds between each frame when 'count'
+// frames are chosen evenly from the video, starting at Fake.Skip.
+func (f *Fake) SpriteInterval(ctx context.Context, count int) (int, error) {
+	skip, err := f.skipSeconds(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	interval := ffmpeg.SpriteIntervalFor(f.Metadata.Duration-skip, count)
+	if interval == 0 {
+		return 0, fmt.Errorf("Video %q is too short to skip to %s.", f.Video, f.Skip)
+	}
+
+	return interval, nil
+}
+
+// CreateThumbnail writes the synthetic frame at Fake.Skip to 'outFile'.
+func (f *Fake) CreateThumbnail(ctx context.Context, width int, outFile string) error {
+	skip, err := f.skipSeconds(ctx)
+	if err != nil {
+		return err
+	}
+	w, h := f.size(width)
+
+	return f.writeImage(f.frame(skip, w, h), outFile)
+}
+
+// CreateBestThumbnail scores the synthetic frames chosen by
+// ffmpeg.CandidateTimes with ffmpeg.ScoreImage, and writes the best one to
+// 'outFile'.
+func (f *Fake) CreateBestThumbnail(ctx context.Context, width int, outFile string) (ffmpeg.Candidate, error) {
+	skip, err := f.skipSeconds(ctx)
+	if err != nil {
+		return ffmpeg.Candidate{}, err
+	}
+
+	best := ffmpeg.Candidate{Time: -1}
+	for _, t := range ffmpeg.CandidateTimes(f.Metadata.Duration, skip, f.Candidates, f.SearchWindow) {
+		score := ffmpeg.ScoreImage(f.frame(t, 160, 90))
+		if best.Time == -1 || score.Total > best.Score.Total {
+			best = ffmpeg.Candidate{Time: t, Score: score}
+		}
+	}
+
+	w, h := f.size(width)
+	return best, f.writeImage(f.frame(best.Time, w, h), outFile)
+}
+
+// CreateThumbnailSprite writes a sprite of synthetic frames to 'outFile', and
+// returns its layout.
+func (f *Fake) CreateThumbnailSprite(ctx context.Context, interval, width int, outFile string) (ffmpeg.Sprite, error) {
+	return f.createSprite(ctx, interval, width, outFile)
+}
+
+// CreateThumbnailVTT writes a sprite of synthetic frames to 'outFile', and a
+// WebVTT thumbnail track for the sprite to 'vttFile'.
+func (f *Fake) CreateThumbnailVTT(ctx context.Context, interval, width int, outFile, vttFile, spriteURL string) error {
+	sprite, err := f.createSprite(ctx, interval, width, outFile)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(vttFile, []byte(ffmpeg.VTTTrack(sprite.Frames, interval, spriteURL)), 0644)
+}
+
+// CreateAnimated writes an animated GIF of synthetic frames to 'outFile'.
+// WebP animations are not supported.
+func (f *Fake) CreateAnimated(ctx context.Context, width int, outFile string) error {
+	if f.Format != ffmpeg.FormatGIF {
+		return fmt.Errorf("%w The fake backend only writes gif animations.", ffmpeg.ErrUnsupportedFormat)
+	}
+	skip, err := f.skipSeconds(ctx)
+	if err != nil {
+		return err
+	}
+	if width == 0 {
+		width = f.Metadata.Width
+	}
+	w, h := width, width*f.Metadata.Height/f.Metadata.Width
+
+	segments := f.Segments
+	if segments < 1 {
+		segments = ffmpeg.DefaultSegments
+	}
+	anim := &gif.GIF{}
+	for i := 0; i < segments; i++ {
+		t := skip + float64(i)*(f.Metadata.Duration-skip)/float64(segments)
+		frame := image.NewPaletted(image.Rect(0, 0, w, h), palette.Plan9)
+		draw.Draw(frame, frame.Bounds(), f.frame(t, w, h), image.Point{}, draw.Src)
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, 10)
+	}
+
+	return writeFile(outFile, func(w io.Writer) error {
+		return gif.EncodeAll(w, anim)
+	})
+}
+
+// CreatePreview writes a placeholder MP4 file to 'outFile'. The file only has
+// a file type box, which is enough to be recognized as an MP4 but can't be
+// played.
+func (f *Fake) CreatePreview(ctx context.Context, width int, outFile string) error {
+	if _, err := f.Probe(ctx); err != nil {
+		return err
+	}
+
+	ftyp := []byte{0, 0, 0, 24, 'f', 't', 'y', 'p', 'i', 's', 'o', 'm', 0, 0, 2, 0, 'i', 's', 'o', 'm', 'm', 'p', '4', '1'}
+	return ioutil.WriteFile(outFile, ftyp, 0644)
+}
+
+// FrameTimes returns 'count' timestamps evenly spaced between Fake.Skip and
+// the end of the video.
+func (f *Fake) FrameTimes(ctx context.Context, count int) ([]ffmpeg.Timestamp, error) {
+	start, err := f.skipSeconds(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return ffmpeg.EvenTimes(start, f.Metadata.Duration, count), nil
+}
+
+// CreateFrames writes the synthetic frames at the given timestamps to separate
+// images named using the template 'outFile'. See ffmpeg.FrameFile.
+func (f *Fake) CreateFrames(ctx context.Context, times []ffmpeg.Timestamp, width int, outFile string) ([]ffmpeg.Still, error) {
+	if len(times) == 0 {
+		return nil, errors.New("No frame timestamps given.")
+	}
+	if _, err := f.Probe(ctx); err != nil {
+		return nil, err
+	}
+
+	w, h := f.size(width)
+	stills := make([]ffmpeg.Still, len(times))
+	for i, t := range times {
+		secs, err := t.Seconds(f.Metadata.Duration, f.Metadata.FrameRate)
+		if err != nil {
+			return nil, err
+		}
+		stills[i] = ffmpeg.Still{Time: secs, File: ffmpeg.FrameFile(outFile, i+1, secs)}
+		if err := f.writeImage(f.frame(secs, w, h), stills[i].File); err != nil {
+			return nil, err
+		}
+	}
+
+	return stills, nil
+}
+
+// CreateWaveform writes a synthetic waveform to 'outFile'. The synthetic video
+// has no cover art, so Fake.CoverArt is ignored.
+func (f *Fake) CreateWaveform(ctx context.Context, width int, outFile string) error {
+	if err := ffmpeg.CheckSize(width, f.Height); err != nil {
+		return err
+	}
+	if _, err := f.Probe(ctx); err != nil {
+		return err
+	}
+	wave, err := ffmpeg.ParseColor(f.WaveColor)
+	if err != nil {
+		return err
+	}
+	bg, err := ffmpeg.ParseColor(f.WaveBackground)
+	if err != nil {
+		return err
+	}
+	if width == 0 {
+		width = ffmpeg.DefaultWaveformWidth
+	}
+	height := f.Height
+	if height == 0 {
+		height = ffmpeg.DefaultWaveformHeight
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{bg}, image.Point{}, draw.Src)
+	for x := 0; x < width; x++ {
+		amp := math.Abs(math.Sin(float64(x)/7)) * (0.3 + 0.7*math.Abs(math.Sin(float64(x)/float64(width)*math.Pi*3)))
+		half := int(amp * float64(height) / 2)
+		for y := height/2 - half; y <= height/2+half && y < height; y++ {
+			img.SetRGBA(x, y, wave)
+		}
+	}
+
+	return f.writeImage(img, outFile)
+}
+
+// CreateContactSheet writes a grid of 'count' synthetic frames, chosen the same
+// way as FrameTimes and laid out with ffmpeg.Options.SheetLayout, to 'outFile'.
+// Space is left for the header, but the header and captions are not drawn.
+func (f *Fake) CreateContactSheet(ctx context.Context, count, width int, outFile string) error {
+	if f.SheetMargin >= 0 {
+		if err := ffmpeg.CheckSheetMargin(f.SheetMargin); err != nil {
+			return err
+		}
+	}
+	if err := ffmpeg.CheckLabelSize(f.LabelSize); err != nil {
+		return err
+	}
+	bg, err := ffmpeg.ParseColor(f.Background)
+	if err != nil {
+		return err
+	}
+	if count < 1 {
+		count = ffmpeg.DefaultSheetTiles
+	}
+	if width == 0 {
+		width = ffmpeg.DefaultSheetTileWidth
+	}
+	m, err := f.Probe(ctx)
+	if err != nil {
+		return err
+	}
+	stamps, err := f.FrameTimes(ctx, count)
+	if err != nil {
+		return err
+	}
+	times := make([]float64, len(stamps))
+	for i, stamp := range stamps {
+		if times[i], err = stamp.Seconds(m.Duration, m.FrameRate); err != nil {
+			return err
+		}
+	}
+
+	w, h := f.size(width)
+	lines := ffmpeg.SheetHeader(filepath.Base(f.Video), m)
+	frames, bounds := f.SheetLayout(times, w, h, len(lines))
+	if err := ffmpeg.CheckCanvas(bounds); err != nil {
+		return err
+	}
+
+	sheet := image.NewRGBA(bounds)
+	draw.Draw(sheet, bounds, &image.Uniform{bg}, image.Point{}, draw.Src)
+	for _, frame := range frames {
+		rect := image.Rect(frame.X, frame.Y, frame.X+w, frame.Y+h)
+		draw.Draw(sheet, rect, f.frame(frame.Time, w, h), image.Point{}, draw.Src)
+	}
+
+	return f.writeImage(sheet, outFile)
+}
+
+// createSprite writes a sprite of synthetic frames to 'outFile', and returns
+// its layout.
+func (f *Fake) createSprite(ctx context.Context, interval, width int, outFile string) (ffmpeg.Sprite, error) {
+	bg, err := ffmpeg.ParseColor(f.Background)
+	if err != nil {
+		return ffmpeg.Sprite{}, err
+	}
+	skip, err := f.skipSeconds(ctx)
+	if err != nil {
+		return ffmpeg.Sprite{}, err
+	}
+	if err := ffmpeg.CheckSpriteInterval(interval); err != nil {
+		return ffmpeg.Sprite{}, err
+	}
+
+	times := ffmpeg.IntervalTimes(skip, f.Metadata.Duration, interval)
+	w, h := f.size(width)
+	sprite := ffmpeg.NewSprite(times, w, h, f.Layout, f.Padding, interval)
+
+	bounds := image.Rect(0, 0, sprite.Width, sprite.Height)
+	if err := ffmpeg.CheckCanvas(bounds); err != nil {
+		return ffmpeg.Sprite{}, err
+	}
+	img := image.NewRGBA(bounds)
+	draw.Draw(img, bounds, &image.Uniform{bg}, image.Point{}, draw.Src)
+	for _, frame := range sprite.Frames {
+		rect := image.Rect(frame.X, frame.Y, frame.X+w, frame.Y+h)
+		draw.Draw(img, rect, f.frame(frame.Time, w, h), image.Point{}, draw.Src)
+	}
+
+	return sprite, f.writeImage(img, outFile)
+}
+
+// skipSeconds returns Fake.Skip in seconds.
+func (f *Fake) skipSeconds(ctx context.Context) (float64, error) {
+	m, err := f.Probe(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return f.Skip.Seconds(m.Duration, m.FrameRate)
+}
+
+// size returns the size of thumbnails 'width' pixels wide, following the same
+// rules as the ffmpeg backend.
+func (f *Fake) size(width int) (int, int) {
+	w, h := f.Metadata.Width, f.Metadata.Height
+	switch {
+	case width == 0 && f.Height == 0:
+		return w, h
+	case f.Height == 0:
+		if width < w {
+			return width, h * width / w
+		}
+		return w, h
+	case width == 0:
+		if f.Height < h {
+			return w * f.Height / h, f.Height
+		}
+		return w, h
+	}
+
+	return width, f.Height
+}
+
+// frame draws the synthetic frame at 't' seconds into the video.
+// The frame is a gradient which changes color over time, with a white bar
+// showing the position of the frame in the video.
+func (f *Fake) frame(t float64, w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	shade := uint8(math.Mod(t*8, 256))
+	bar := int(t / f.Metadata.Duration * float64(w))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{uint8(x * 255 / w), uint8(y * 255 / h), shade, 0xff}
+			if x >= bar && x < bar+w/20+1 {
+				c = color.RGBA{0xff, 0xff, 0xff, 0xff}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	return img
+}
+
+// writeImage writes the image to 'outFile' in Fake.Format. JPEG, PNG and GIF
+// images are supported.
+func (f *Fake) writeImage(img image.Image, outFile string) error {
+	switch f.Format {
+	case "", ffmpeg.FormatJPEG:
+		quality := f.Quality
+		if quality == 0 {
+			quality = ffmpeg.DefaultQuality
+		}
+		return writeFile(outFile, func(w io.Writer) error {
+			return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+		})
+	case ffmpeg.FormatPNG:
+		return writeFile(outFile, func(w io.Writer) error {
+			return png.Encode(w, img)
+		})
+	case ffmpeg.FormatGIF:
+		return writeFile(outFile, func(w io.Writer) error {
+			return gif.Encode(w, img, nil)
+		})
+	}
+
+	return fmt.Errorf("%w The fake backend cannot write %s images.", ffmpeg.ErrUnsupportedFormat, f.Format)
+}
+
+// writeFile creates 'file' and writes to it using the given encode function.
+// The file is removed when an error occurs.
+func writeFile(file string, encode func(io.Writer) error) error {
+	fout, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err = encode(fout); err != nil {
+		fout.Close()
+		os.Remove(file)
+		return err
+	}
+
+	return fout.Close()
+}
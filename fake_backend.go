@@ -0,0 +1,10 @@
+//go:build fake
+// +build fake
+
+package main
+
+// The fake backend is only built into binaries built with the "fake" tag, eg
+// "go build -tags fake", so it can't be chosen by mistake in production.
+import (
+	_ "github.com/dulo-tech/service-thumbnails/fake"
+)
@@ -0,0 +1,169 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Default values for the animated thumbnail options.
+const (
+	DefaultSegments      = 5
+	DefaultSegmentLength = 1.0
+	DefaultFPS           = 10
+)
+
+// CreateAnimated creates a short looping animation from the video.
+// The animation is made from FFmpeg.Segments evenly spaced excerpts of the
+// video, each FFmpeg.SegmentLength seconds long, played at FFmpeg.FPS frames
+// per second. FFmpeg.Format must be either FormatGIF or FormatWebP. GIF
+// animations use a palette generated from the frames when FFmpeg.Palette is
+// true, which looks much better but takes longer. When 0 is given for the
+// 'width' argument, the animation will have the same width of the video.
+func (f *FFmpeg) CreateAnimated(ctx context.Context, width int, outFile string) error {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+	os.Remove(outFile)
+
+	if f.Format != FormatGIF && f.Format != FormatWebP {
+		return fmt.Errorf("Animated thumbnails must be gif or webp, not %q.", f.Format)
+	}
+	enc, err := f.encoder(ctx, f.Format)
+	if err != nil {
+		return err
+	}
+	fps := f.FPS
+	if fps < 1 {
+		fps = DefaultFPS
+	}
+
+	starts, length, err := f.segmentTimes(ctx)
+	if err != nil {
+		return err
+	}
+
+	orient, err := f.orientFilter(ctx)
+	if err != nil {
+		return err
+	}
+	filters := fmt.Sprintf("fps=%d", fps)
+	if width != 0 {
+		filters = joinFilters(filters, scaleFilter(width))
+	}
+	graph := segmentGraph(len(starts), joinFilters(orient, filters))
+	if f.Format == FormatGIF && f.Palette {
+		graph += ";[out]split[a][b];[a]palettegen=stats_mode=diff[p];[b][p]paletteuse=dither=bayer[pal]"
+	}
+
+	args := segmentInputs(f.Video, starts, length)
+	args = append(args, "-filter_complex", graph)
+	if f.Format == FormatGIF && f.Palette {
+		args = append(args, "-map", "[pal]")
+	} else {
+		args = append(args, "-map", "[out]")
+	}
+	args = append(args, "-an", "-loop", "0")
+	args = append(args, enc.animatedArgs()...)
+	args = append(args, outFile)
+
+	err = run(ctx, CmdFFmpeg, args...)
+	if err != nil {
+		os.Remove(outFile)
+		return err
+	}
+
+	return nil
+}
+
+// segmentTimes returns the start times of FFmpeg.Segments evenly spaced
+// excerpts of the video, along with the length of each excerpt. Fewer and
+// shorter excerpts are used when the video is too short to fit them.
+func (f *FFmpeg) segmentTimes(ctx context.Context) ([]float64, float64, error) {
+	m, err := f.Probe(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	if m.IsImage() && !m.IsAnimated() {
+		return nil, 0, fmt.Errorf("Cannot create an animation from still image %q.", f.Video)
+	}
+	duration := m.Duration
+
+	count := f.Segments
+	if count < 1 {
+		count = DefaultSegments
+	}
+	length := f.SegmentLength
+	if length <= 0 {
+		length = DefaultSegmentLength
+	}
+
+	start, err := f.skipSeconds(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	remaining := duration - start
+	if remaining <= 0 {
+		return nil, 0, fmt.Errorf("Video %q is too short to skip to %s.", f.Video, f.Skip)
+	}
+	if remaining < length {
+		length = remaining
+	}
+	if fit := int(math.Floor(remaining / length)); count > fit {
+		count = fit
+	}
+
+	// Each excerpt is centered in an equal share of the remaining video.
+	share := remaining / float64(count)
+	starts := make([]float64, count)
+	for i := range starts {
+		starts[i] = start + float64(i)*share + (share-length)/2
+	}
+
+	return starts, length, nil
+}
+
+// segmentInputs returns the ffmpeg arguments which open one input for each
+// excerpt of the video, starting at the given times. The inputs are not
+// rotated automatically, so the filters should start with orientFilter.
+func segmentInputs(video string, starts []float64, length float64) []string {
+	args := []string{}
+	for _, start := range starts {
+		args = append(args,
+			"-noautorotate",
+			"-ss",
+			formatSeconds(start),
+			"-t",
+			formatSeconds(length),
+			"-i",
+			video)
+	}
+
+	return args
+}
+
+// segmentGraph returns a filter graph which applies 'filters' to the video of
+// each of 'n' inputs, and joins them into a single stream labeled "out".
+func segmentGraph(n int, filters string) string {
+	chains := make([]string, 0, n+1)
+	labels := ""
+	for i := 0; i < n; i++ {
+		chains = append(chains, fmt.Sprintf("[%d:v]%s,setpts=PTS-STARTPTS[v%d]", i, filters, i))
+		labels += fmt.Sprintf("[v%d]", i)
+	}
+	chains = append(chains, fmt.Sprintf("%sconcat=n=%d:v=1:a=0[out]", labels, n))
+
+	return strings.Join(chains, ";")
+}
+
+// animatedArgs returns the ffmpeg output arguments used to write animations.
+func (e encoder) animatedArgs() []string {
+	args := []string{"-c:v", e.codec, "-f", string(e.format)}
+	if e.format == FormatWebP && e.quality > 0 {
+		args = append(args, "-quality", strconv.Itoa(e.quality))
+	}
+
+	return args
+}
@@ -0,0 +1,69 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DefaultBackend is the name of the backend which uses the ffmpeg command.
+const DefaultBackend = "ffmpeg"
+
+// Backend creates a VideoThumbnailer for the given video using the given options.
+type Backend func(video string, opts Options) VideoThumbnailer
+
+var (
+	// backends stores the registered backends by name.
+	backends = map[string]Backend{}
+	// backendsMutex guards backends.
+	backendsMutex sync.RWMutex
+)
+
+func init() {
+	Register(DefaultBackend, func(video string, opts Options) VideoThumbnailer {
+		return NewWithOptions(video, opts)
+	})
+}
+
+// Register makes a backend available by the given name.
+// Register panics when a backend is registered twice with the same name.
+func Register(name string, backend Backend) {
+	backendsMutex.Lock()
+	defer backendsMutex.Unlock()
+
+	if _, ok := backends[name]; ok {
+		panic("ffmpeg: Register called twice for backend " + name)
+	}
+	backends[name] = backend
+}
+
+// Backends returns the names of the registered backends in sorted order.
+func Backends() []string {
+	backendsMutex.RLock()
+	defer backendsMutex.RUnlock()
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Open creates a VideoThumbnailer for the given video using the backend
+// registered with the given name. An empty name selects DefaultBackend.
+func Open(name, video string, opts Options) (VideoThumbnailer, error) {
+	if name == "" {
+		name = DefaultBackend
+	}
+
+	backendsMutex.RLock()
+	backend, ok := backends[name]
+	backendsMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("Invalid backend %q.", name)
+	}
+
+	return backend(video, opts), nil
+}
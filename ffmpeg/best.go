@@ -0,0 +1,126 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+)
+
+// DefaultCandidates is the number of frames considered by CreateBestThumbnail
+// when FFmpeg.Candidates is 0.
+const DefaultCandidates = 8
+
+// candidateWidth is the width candidate frames are scaled down to before
+// they're scored.
+const candidateWidth = 320
+
+// Candidate is a frame considered when choosing the best thumbnail.
+type Candidate struct {
+	// Time is the position of the frame in the video, in seconds.
+	Time float64
+	// Score rates how well the frame works as a thumbnail.
+	Score FrameScore
+}
+
+// CreateBestThumbnail creates a single thumbnail from the frame which works
+// best as a thumbnail, and returns the chosen frame.
+// Several candidate frames are taken from around FFmpeg.Skip, or from
+// across the whole video when FFmpeg.SearchWindow is 0. Each candidate is
+// scored with ScoreImage, which prefers frames that are not black, blurred or
+// mostly a single shade. The image is written in FFmpeg.Format.
+// When 0 is given for the 'width' argument, the thumbnail will have the same
+// width of the video. FFmpeg.Label and FFmpeg.Logo are drawn over the chosen
+// frame when they're set.
+func (f *FFmpeg) CreateBestThumbnail(ctx context.Context, width int, outFile string) (Candidate, error) {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	enc, err := f.encoder(ctx, f.Format)
+	if err != nil {
+		return Candidate{}, err
+	}
+	filter, err := f.frameFilter(ctx, width)
+	if err != nil {
+		return Candidate{}, err
+	}
+	times, err := f.candidateTimes(ctx)
+	if err != nil {
+		return Candidate{}, err
+	}
+
+	tmp, err := ioutil.TempDir(TempDirectory, "thumb")
+	if err != nil {
+		return Candidate{}, err
+	}
+	defer os.RemoveAll(tmp)
+
+	files, err := f.extractFrames(ctx, times, scaleFilter(candidateWidth), nil, tmp)
+	if err != nil {
+		return Candidate{}, err
+	}
+
+	best := Candidate{Time: -1}
+	for i, file := range files {
+		img, err := decodeImage(file)
+		if err != nil {
+			// Candidates past the last frame of the video are not written.
+			continue
+		}
+		score := ScoreImage(img)
+		if best.Time == -1 || score.Total > best.Score.Total {
+			best = Candidate{Time: times[i], Score: score}
+		}
+	}
+	if best.Time == -1 {
+		return Candidate{}, fmt.Errorf("No frames extracted from video %q.", f.Video)
+	}
+
+	return best, f.extractThumbnail(ctx, best.Time, filter, outFile, enc)
+}
+
+// candidateTimes returns the times of the frames considered by CreateBestThumbnail.
+func (f *FFmpeg) candidateTimes(ctx context.Context) ([]float64, error) {
+	length, err := f.Length(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	skip := 0.0
+	if f.SearchWindow > 0 {
+		if skip, err = f.skipSeconds(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return CandidateTimes(length, skip, f.Candidates, f.SearchWindow), nil
+}
+
+// CandidateTimes returns the times of 'count' frames considered as thumbnails
+// of a video 'length' seconds long. The frames are spread across the whole
+// video, or within 'window' seconds of 'skip' when 'window' is above 0.
+// DefaultCandidates are returned when 'count' is 0.
+func CandidateTimes(length, skip float64, count, window int) []float64 {
+	if count < 1 {
+		count = DefaultCandidates
+	}
+
+	// The very beginning and end of a video are usually fades or credits.
+	start, end := length*0.05, length*0.95
+	if window > 0 {
+		start = math.Max(0, skip-float64(window))
+		end = math.Min(length, skip+float64(window))
+	}
+	if count == 1 || end <= start {
+		return []float64{start}
+	}
+
+	times := make([]float64, count)
+	step := (end - start) / float64(count-1)
+	for i := range times {
+		times[i] = start + float64(i)*step
+	}
+
+	return times
+}
@@ -0,0 +1,186 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// namedColors maps the color names accepted by ParseColor to their values.
+var namedColors = map[string]color.RGBA{
+	"black":  {0x00, 0x00, 0x00, 0xff},
+	"white":  {0xff, 0xff, 0xff, 0xff},
+	"gray":   {0x80, 0x80, 0x80, 0xff},
+	"grey":   {0x80, 0x80, 0x80, 0xff},
+	"red":    {0xff, 0x00, 0x00, 0xff},
+	"green":  {0x00, 0x80, 0x00, 0xff},
+	"blue":   {0x00, 0x00, 0xff, 0xff},
+	"yellow": {0xff, 0xff, 0x00, 0xff},
+}
+
+// Limits on the size of the images drawn from several frames, such as sprites
+// and contact sheets. WebP cannot store images larger than 16383 pixels on
+// either side.
+const (
+	MaxCanvasSize   = 16383
+	MaxCanvasPixels = 40000000
+)
+
+// ErrCanvasTooLarge is returned when a sprite or contact sheet would be larger
+// than MaxCanvasSize or MaxCanvasPixels.
+var ErrCanvasTooLarge = errors.New("The image is too large. Use fewer or smaller frames.")
+
+// CheckCanvas returns ErrCanvasTooLarge when an image with the given bounds is
+// wider or taller than MaxCanvasSize, or has more than MaxCanvasPixels pixels.
+func CheckCanvas(bounds image.Rectangle) error {
+	w, h := bounds.Dx(), bounds.Dy()
+	if w > MaxCanvasSize || h > MaxCanvasSize || w*h > MaxCanvasPixels {
+		return ErrCanvasTooLarge
+	}
+	return nil
+}
+
+// stitchFrames draws the given frame files onto a single image using the
+// positions in 'frames', and writes the image to 'outFile' in FFmpeg.Format.
+func (f *FFmpeg) stitchFrames(ctx context.Context, files []string, frames []SpriteFrame, outFile string) error {
+	bounds := image.Rectangle{}
+	for _, frame := range frames {
+		bounds = bounds.Union(image.Rect(frame.X, frame.Y, frame.X+frame.Width, frame.Y+frame.Height))
+	}
+	sprite, err := f.drawFrames(bounds, files, frames)
+	if err != nil {
+		return err
+	}
+
+	return f.encodeImage(ctx, sprite, outFile)
+}
+
+// drawFrames draws the given frame files onto an image with the given bounds
+// using the positions in 'frames'. The rest of the image is filled with
+// FFmpeg.Background. ErrCanvasTooLarge is returned before the image is
+// allocated when the bounds are too large.
+func (f *FFmpeg) drawFrames(bounds image.Rectangle, files []string, frames []SpriteFrame) (*image.RGBA, error) {
+	if err := CheckCanvas(bounds); err != nil {
+		return nil, err
+	}
+	bg, err := ParseColor(f.Background)
+	if err != nil {
+		return nil, err
+	}
+
+	img := image.NewRGBA(bounds)
+	draw.Draw(img, bounds, &image.Uniform{bg}, bounds.Min, draw.Src)
+	for i, file := range files {
+		frameImg, err := decodeImage(file)
+		if err != nil {
+			return nil, err
+		}
+		frame := frames[i]
+		rect := image.Rect(frame.X, frame.Y, frame.X+frame.Width, frame.Y+frame.Height)
+		draw.Draw(img, rect, frameImg, frameImg.Bounds().Min, draw.Src)
+	}
+
+	return img, nil
+}
+
+// encodeImage writes the image to 'outFile' in FFmpeg.Format.
+// JPEG and PNG images are encoded in process. Other formats are written to a
+// temporary PNG which ffmpeg converts.
+func (f *FFmpeg) encodeImage(ctx context.Context, img image.Image, outFile string) error {
+	switch f.Format {
+	case "", FormatJPEG:
+		quality := f.Quality
+		if quality == 0 {
+			quality = DefaultQuality
+		}
+		return writeImage(outFile, func(w io.Writer) error {
+			return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+		})
+	case FormatPNG:
+		return writeImage(outFile, func(w io.Writer) error {
+			return png.Encode(w, img)
+		})
+	}
+
+	enc, err := f.encoder(ctx, f.Format)
+	if err != nil {
+		return err
+	}
+
+	temp, err := ioutil.TempFile(TempDirectory, "sprite")
+	if err != nil {
+		return err
+	}
+	temp.Close()
+	defer os.Remove(temp.Name())
+	err = writeImage(temp.Name(), func(w io.Writer) error {
+		return png.Encode(w, img)
+	})
+	if err != nil {
+		return err
+	}
+
+	args := []string{"-f", "png_pipe", "-i", temp.Name()}
+	args = append(args, enc.args()...)
+	args = append(args, outFile)
+	return run(ctx, CmdFFmpeg, args...)
+}
+
+// writeImage creates 'file' and writes to it using the given encode function.
+func writeImage(file string, encode func(io.Writer) error) error {
+	fout, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err = encode(fout); err != nil {
+		fout.Close()
+		return err
+	}
+
+	return fout.Close()
+}
+
+// decodeImage reads and decodes the given image file.
+func decodeImage(file string) (image.Image, error) {
+	fin, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fin.Close()
+
+	img, _, err := image.Decode(fin)
+	return img, err
+}
+
+// ParseColor converts a color name, or a hex color in the format "#rgb" or
+// "#rrggbb", into a color.RGBA.
+func ParseColor(s string) (color.RGBA, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if c, ok := namedColors[s]; ok {
+		return c, nil
+	}
+
+	hex := strings.TrimPrefix(strings.TrimPrefix(s, "#"), "0x")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("Invalid color %q.", s)
+	}
+	x, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("Invalid color %q.", s)
+	}
+
+	return color.RGBA{uint8(x >> 16), uint8(x >> 8), uint8(x), 0xff}, nil
+}
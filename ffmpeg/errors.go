@@ -0,0 +1,156 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// maxStderrBytes is the number of bytes kept from the end of a command's stderr.
+	maxStderrBytes = 8192
+	// maxStderrLines is the number of lines from the end of stderr stored in an Error.
+	maxStderrLines = 10
+)
+
+// invalidInputMessages are messages written to stderr by ffmpeg and ffprobe
+// when the input file is damaged or is not a video.
+var invalidInputMessages = []string{
+	"invalid data found when processing input",
+	"moov atom not found",
+	"could not find codec parameters",
+	"no such file or directory",
+	"unknown format",
+	"error while decoding stream",
+	"invalid nal unit size",
+	"ebml header parsing failed",
+}
+
+// noVideoStreamMessages are messages written to stderr by ffmpeg when the
+// input file does not contain a video stream.
+var noVideoStreamMessages = []string{
+	"does not contain any stream",
+	"matches no streams",
+	"stream specifier ':v' in filtergraph",
+	"output file #0 does not contain",
+}
+
+// Error is returned when an ffmpeg or ffprobe command fails.
+type Error struct {
+	// Command is the command line which was run.
+	Command []string
+	// ExitCode is the exit status of the command, or -1 when the command did
+	// not exit normally, eg it was killed.
+	ExitCode int
+	// Elapsed is how long the command ran.
+	Elapsed time.Duration
+	// Stderr holds the last lines the command wrote to stderr.
+	Stderr string
+	// Err is the underlying error. It's the context error when the command was
+	// killed because its context was done.
+	Err error
+}
+
+// Error implements error.Error.
+func (e *Error) Error() string {
+	name := "command"
+	if len(e.Command) > 0 {
+		name = filepath.Base(e.Command[0])
+	}
+
+	switch {
+	case errors.Is(e.Err, context.DeadlineExceeded):
+		return fmt.Sprintf("%s timed out after %s", name, e.Elapsed.Round(time.Millisecond))
+	case errors.Is(e.Err, context.Canceled):
+		return fmt.Sprintf("%s was cancelled after %s", name, e.Elapsed.Round(time.Millisecond))
+	}
+
+	msg := fmt.Sprintf("%s failed with exit status %d", name, e.ExitCode)
+	if line := e.lastLine(); line != "" {
+		msg += ": " + line
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// CommandLine returns the command which was run as a single string.
+func (e *Error) CommandLine() string {
+	return strings.Join(e.Command, " ")
+}
+
+// lastLine returns the last non empty line written to stderr.
+func (e *Error) lastLine() string {
+	lines := strings.Split(strings.TrimSpace(e.Stderr), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// stderrContains returns whether stderr contains any of the given messages.
+func (e *Error) stderrContains(messages []string) bool {
+	stderr := strings.ToLower(e.Stderr)
+	for _, msg := range messages {
+		if strings.Contains(stderr, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInvalidInput returns whether the error was caused by an input file which is
+// damaged, missing, or not a video.
+func IsInvalidInput(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.stderrContains(invalidInputMessages)
+}
+
+// IsNoVideoStream returns whether the error was caused by an input file which
+// does not contain a video stream.
+func IsNoVideoStream(err error) bool {
+	if errors.Is(err, ErrNoVideoStream) {
+		return true
+	}
+	var e *Error
+	return errors.As(err, &e) && e.stderrContains(noVideoStreamMessages)
+}
+
+// IsTimeout returns whether the error was caused by an operation running
+// longer than FFmpeg.Timeout, or its context deadline.
+func IsTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// IsCanceled returns whether the error was caused by an operation's context
+// being cancelled.
+func IsCanceled(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
+// tailBuffer is an io.Writer which keeps the last 'max' bytes written to it.
+type tailBuffer struct {
+	buf []byte
+	max int
+}
+
+// Write implements io.Writer.Write.
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.max {
+		t.buf = t.buf[len(t.buf)-t.max:]
+	}
+	return len(p), nil
+}
+
+// Lines returns the last 'n' lines written to the buffer.
+func (t *tailBuffer) Lines(n int) string {
+	lines := strings.Split(strings.TrimRight(string(t.buf), "\r\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
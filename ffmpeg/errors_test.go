@@ -0,0 +1,166 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Stderr written by ffmpeg and ffprobe for common failures.
+const (
+	stderrNotVideo = `ffprobe version 6.1.1 Copyright (c) 2007-2023 the FFmpeg developers
+  built with gcc 13 (GCC)
+[in#0 @ 0x5583c4f0a2c0] Error opening input: Invalid data found when processing input
+notes.txt: Invalid data found when processing input`
+	stderrMoov = `[mov,mp4,m4a,3gp,3g2,mj2 @ 0x55d0a3c7e440] moov atom not found
+[in#0 @ 0x55d0a3c7e2c0] Error opening input: Invalid data found when processing input
+Error opening input file truncated.mp4.
+Error opening input files: Invalid data found when processing input`
+	stderrMissing = `[in#0 @ 0x5609b2f3a2c0] Error opening input: No such file or directory
+Error opening input file missing.mp4.
+Error opening input files: No such file or directory`
+	stderrAudioOnly = `Input #0, mp3, from 'song.mp3':
+  Duration: 00:03:25.02, start: 0.025057, bitrate: 320 kb/s
+  Stream #0:0: Audio: mp3, 44100 Hz, stereo, fltp, 320 kb/s
+[out#0/image2 @ 0x55f0c1a8e880] Output file #0 does not contain any stream
+Error opening output file thumb.jpg.
+Error opening output files: Invalid argument`
+	stderrNoMatch = `Input #0, mp3, from 'song.mp3':
+  Stream #0:0: Audio: mp3, 44100 Hz, stereo, fltp, 320 kb/s
+Stream specifier ':v' in filtergraph description [0:v]scale=180:-1 matches no streams.`
+	stderrEncoder = `Input #0, mov,mp4,m4a,3gp,3g2,mj2, from 'video.mp4':
+  Stream #0:0[0x1](und): Video: h264 (High) (avc1 / 0x31637661), yuv420p, 1920x1080, 25 fps
+[vost#0:0 @ 0x5612a0e1c900] Unknown encoder 'libwebp'
+Error selecting an encoder`
+)
+
+func TestTailBuffer(t *testing.T) {
+	tests := []struct {
+		name   string
+		max    int
+		writes []string
+		lines  int
+		want   string
+	}{
+		{"empty", 16, nil, 3, ""},
+		{"under limit", 16, []string{"a\n", "b\n"}, 3, "a\nb"},
+		{"last lines", 64, []string{"one\ntwo\nthree\nfour\n"}, 2, "three\nfour"},
+		{"wraps past limit", 8, []string{"first\n", "second\n", "third\n"}, 5, "d\nthird"},
+		{"single write past limit", 5, []string{"abcdefghij"}, 1, "fghij"},
+		{"crlf", 64, []string{"one\r\ntwo\r\n"}, 1, "two"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := &tailBuffer{max: test.max}
+			for _, w := range test.writes {
+				if n, err := b.Write([]byte(w)); n != len(w) || err != nil {
+					t.Fatalf("Write(%q) = %d, %v, want %d, nil", w, n, err, len(w))
+				}
+			}
+			if len(b.buf) > test.max {
+				t.Errorf("buffer holds %d bytes, want at most %d", len(b.buf), test.max)
+			}
+			if got := b.Lines(test.lines); got != test.want {
+				t.Errorf("Lines(%d) = %q, want %q", test.lines, got, test.want)
+			}
+		})
+	}
+}
+
+func TestTailBufferKeepsEnd(t *testing.T) {
+	b := &tailBuffer{max: maxStderrBytes}
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(b, "frame=%5d fps=25 q=2.0 size=N/A time=00:00:%02d.00\n", i, i%60)
+	}
+	b.Write([]byte(stderrNotVideo))
+
+	if len(b.buf) != maxStderrBytes {
+		t.Errorf("buffer holds %d bytes, want %d", len(b.buf), maxStderrBytes)
+	}
+	lines := strings.Split(b.Lines(maxStderrLines), "\n")
+	if len(lines) != maxStderrLines || lines[len(lines)-1] != "notes.txt: Invalid data found when processing input" {
+		t.Errorf("Lines(%d) = %q", maxStderrLines, lines)
+	}
+}
+
+func TestErrorError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *Error
+		want string
+	}{
+		{
+			name: "exit status",
+			err:  &Error{Command: []string{"/usr/bin/ffprobe", "notes.txt"}, ExitCode: 1, Stderr: stderrNotVideo},
+			want: "ffprobe failed with exit status 1: notes.txt: Invalid data found when processing input",
+		},
+		{
+			name: "trailing blank lines",
+			err:  &Error{Command: []string{"ffmpeg"}, ExitCode: 1, Stderr: stderrEncoder + "\n\n"},
+			want: "ffmpeg failed with exit status 1: Error selecting an encoder",
+		},
+		{
+			name: "no stderr",
+			err:  &Error{Command: []string{"ffmpeg"}, ExitCode: 234},
+			want: "ffmpeg failed with exit status 234",
+		},
+		{
+			name: "no command",
+			err:  &Error{ExitCode: 1},
+			want: "command failed with exit status 1",
+		},
+		{
+			name: "timeout",
+			err:  &Error{Command: []string{"ffmpeg"}, ExitCode: -1, Elapsed: 1500 * time.Millisecond, Err: context.DeadlineExceeded},
+			want: "ffmpeg timed out after 1.5s",
+		},
+		{
+			name: "cancelled",
+			err:  &Error{Command: []string{"ffmpeg"}, ExitCode: -1, Elapsed: 250 * time.Millisecond, Err: context.Canceled},
+			want: "ffmpeg was cancelled after 250ms",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.err.Error(); got != test.want {
+				t.Errorf("Error() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		invalidInput  bool
+		noVideoStream bool
+	}{
+		{"not a video", &Error{Stderr: stderrNotVideo}, true, false},
+		{"truncated mp4", &Error{Stderr: stderrMoov}, true, false},
+		{"missing file", &Error{Stderr: stderrMissing}, true, false},
+		{"audio only", &Error{Stderr: stderrAudioOnly}, false, true},
+		{"no matching stream", &Error{Stderr: stderrNoMatch}, false, true},
+		{"unknown encoder", &Error{Stderr: stderrEncoder}, false, false},
+		{"wrapped", fmt.Errorf("sprite: %w", &Error{Stderr: stderrMoov}), true, false},
+		{"no video stream", ErrNoVideoStream, false, true},
+		{"wrapped no video stream", fmt.Errorf("probe: %w", ErrNoVideoStream), false, true},
+		{"other error", errors.New("moov atom not found"), false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsInvalidInput(test.err); got != test.invalidInput {
+				t.Errorf("IsInvalidInput() = %v, want %v", got, test.invalidInput)
+			}
+			if got := IsNoVideoStream(test.err); got != test.noVideoStream {
+				t.Errorf("IsNoVideoStream() = %v, want %v", got, test.noVideoStream)
+			}
+		})
+	}
+}
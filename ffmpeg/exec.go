@@ -0,0 +1,60 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"time"
+)
+
+// withTimeout returns a copy of ctx which is cancelled once FFmpeg.Timeout
+// has elapsed. The context is only cancelled by the parent when the timeout is 0.
+func (f *FFmpeg) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if f.Timeout > 0 {
+		return context.WithTimeout(ctx, f.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// run runs the given command and waits for it to finish.
+// The command is killed when ctx is done. An *Error is returned when the
+// command fails.
+func run(ctx context.Context, name string, args ...string) error {
+	_, err := output(ctx, name, args...)
+	return err
+}
+
+// output runs the given command and returns what it wrote to stdout.
+// The command is killed when ctx is done. An *Error is returned when the
+// command fails.
+func output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	stdout := bytes.Buffer{}
+	stderr := &tailBuffer{max: maxStderrBytes}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	if err == nil && ctx.Err() == nil {
+		return stdout.Bytes(), nil
+	}
+
+	e := &Error{
+		Command:  append([]string{name}, args...),
+		ExitCode: -1,
+		Elapsed:  time.Since(start),
+		Stderr:   stderr.Lines(maxStderrLines),
+		Err:      err,
+	}
+	if ctx.Err() != nil {
+		e.Err = ctx.Err()
+	}
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		e.ExitCode = ee.ExitCode()
+	}
+
+	return nil, e
+}
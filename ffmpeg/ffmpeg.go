@@ -1,12 +1,17 @@
 package ffmpeg
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"image"
+	_ "image/jpeg"
 	"io/ioutil"
 	"os"
-	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 var (
@@ -16,25 +21,48 @@ var (
 	CmdFFprobe string
 	// CmdFFmpeg is the ffmpeg command to use.
 	CmdFFmpeg string
-	// CmdConvert is the convert command to use.
-	CmdConvert string
 )
 
+// DefaultQuality is the JPEG quality used for sprites when FFmpeg.Quality is 0.
+// Other images use the default quality of the ffmpeg encoder.
+const DefaultQuality = 90
+
 // VideoThumbnailer describes a type which creates thumbnails from videos.
+// Backends for the interface are registered with Register, and created with Open.
 type VideoThumbnailer interface {
-	Length() float64
-	CreateThumbnail(int, string) error
-	CreateThumbnailSprite(int, int, string) error
+	Probe(context.Context) (*Metadata, error)
+	Length(context.Context) (float64, error)
+	SpriteInterval(context.Context, int) (int, error)
+	CreateThumbnail(context.Context, int, string) error
+	CreateBestThumbnail(context.Context, int, string) (Candidate, error)
+	CreateThumbnailSprite(context.Context, int, int, string) (Sprite, error)
+	CreateThumbnailVTT(context.Context, int, int, string, string, string) error
+	CreateAnimated(context.Context, int, string) error
+	CreatePreview(context.Context, int, string) error
+	FrameTimes(context.Context, int) ([]Timestamp, error)
+	CreateFrames(context.Context, []Timestamp, int, string) ([]Still, error)
+	CreateWaveform(context.Context, int, string) error
+	CreateContactSheet(context.Context, int, int, string) error
 }
 
+// FFmpeg must implement VideoThumbnailer.
+var _ VideoThumbnailer = (*FFmpeg)(nil)
+
 // FFmpeg is used to create thumbnails from videos.
 type FFmpeg struct {
-	SkipSeconds int
-	Video       string
+	Options
+	Video string
+	// metadata caches the value returned by Probe.
+	metadata *Metadata
 }
 
-// New creates and returns a new FFmpeg instance.
+// New creates and returns a new FFmpeg instance using DefaultOptions.
 func New(video string) *FFmpeg {
+	return NewWithOptions(video, DefaultOptions())
+}
+
+// NewWithOptions creates and returns a new FFmpeg instance using the given options.
+func NewWithOptions(video string, opts Options) *FFmpeg {
 	if TempDirectory == "" {
 		TempDirectory = os.TempDir()
 	}
@@ -44,66 +72,164 @@ func New(video string) *FFmpeg {
 	if CmdFFmpeg == "" {
 		CmdFFmpeg = "ffmpeg"
 	}
-	if CmdConvert == "" {
-		CmdConvert = "convert"
-	}
 
 	return &FFmpeg{
-		SkipSeconds: 0,
-		Video:       video,
+		Options: opts,
+		Video:   video,
+	}
+}
+
+// Probe returns the metadata for the video.
+// The metadata is read once and cached for subsequent calls.
+func (f *FFmpeg) Probe(ctx context.Context) (*Metadata, error) {
+	if f.metadata == nil {
+		ctx, cancel := f.withTimeout(ctx)
+		defer cancel()
+
+		m, err := Probe(ctx, f.Video)
+		if err != nil {
+			return nil, err
+		}
+		if m.IsImage() {
+			f.logf("Reading %q as an image.", f.Video)
+		}
+		f.metadata = m
 	}
+
+	return f.metadata, nil
 }
 
 // Length returns the length of the video in seconds.
-func (f *FFmpeg) Length() float64 {
-	output, err := exec.Command(
-		CmdFFprobe,
-		"-i",
-		f.Video,
-		"-v",
-		"quiet",
-		"-show_entries",
-		"format=duration",
-		"-of",
-		"csv=p=0",
-	).Output()
+func (f *FFmpeg) Length(ctx context.Context) (float64, error) {
+	m, err := f.Probe(ctx)
+	if err != nil {
+		return 0.0, err
+	}
+
+	return m.Duration, nil
+}
+
+// skipSeconds returns FFmpeg.Skip in seconds. The video is probed when the
+// timestamp is not the start of the video, which makes sure it falls
+// before the end of the video. Images always start at their first frame.
+func (f *FFmpeg) skipSeconds(ctx context.Context) (float64, error) {
+	if f.Skip.IsZero() {
+		return 0, nil
+	}
+	m, err := f.Probe(ctx)
 	if err != nil {
-		return 0.0
+		return 0, err
 	}
+	if m.IsImage() {
+		f.logf("Ignoring skip position %s for image %q.", f.Skip, f.Video)
+		return 0, nil
+	}
+
+	return f.Skip.Seconds(m.Duration, m.FrameRate)
+}
 
-	len, err := strconv.ParseFloat(strings.Trim(string(output), "\n"), 64)
+// SpriteInterval returns the number of seconds between each frame when 'count'
+// frames are chosen evenly from the video, starting at FFmpeg.Skip.
+// The interval is never less than 1 second, which means short videos produce
+// fewer than 'count' frames. The interval of an image is always 1 second, as
+// sprites of images are made from every frame of the image.
+func (f *FFmpeg) SpriteInterval(ctx context.Context, count int) (int, error) {
+	m, err := f.Probe(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if m.IsImage() {
+		return 1, nil
+	}
+	length := m.Duration
+	skip, err := f.skipSeconds(ctx)
 	if err != nil {
-		return 0.0
+		return 0, err
+	}
+
+	interval := SpriteIntervalFor(length-skip, count)
+	if interval == 0 {
+		return 0, fmt.Errorf("Video %q is too short to skip to %s.", f.Video, f.Skip)
+	}
+
+	return interval, nil
+}
+
+// SpriteIntervalFor returns the number of whole seconds between 'count' frames
+// chosen evenly from 'remaining' seconds of video. The interval is at least 1
+// second, or 0 when less than a second of video remains.
+func SpriteIntervalFor(remaining float64, count int) int {
+	secs := int(remaining)
+	if secs < 1 {
+		return 0
+	}
+	if count < 1 {
+		count = 1
+	}
+	interval := secs / count
+	if interval < 1 {
+		interval = 1
 	}
 
-	return len
+	return interval
 }
 
 // CreateThumbnail creates a single thumbnail from the video.
-// The frame is determined by the value of FFmpeg.SkipSeconds.
+// The frame is determined by the value of FFmpeg.Skip, and the image
+// is written in FFmpeg.Format.
 // When 0 is given for the 'width' argument, the thumbnail will have the same
-// width of the video.
-func (f *FFmpeg) CreateThumbnail(width int, outFile string) error {
+// width of the video. When FFmpeg.Height is also given, the thumbnail is
+// exactly 'width' by FFmpeg.Height pixels, sized using FFmpeg.Fit.
+// The frame is rotated and stretched to square pixels before it's sized, so
+// the thumbnail looks the way the video is displayed by players.
+// The thumbnail of an image is made from its first frame. FFmpeg.Label and
+// FFmpeg.Logo are drawn over the thumbnail when they're set.
+func (f *FFmpeg) CreateThumbnail(ctx context.Context, width int, outFile string) error {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	enc, err := f.encoder(ctx, f.Format)
+	if err != nil {
+		return err
+	}
+
+	filter, err := f.frameFilter(ctx, width)
+	if err != nil {
+		return err
+	}
+	skip, err := f.skipSeconds(ctx)
+	if err != nil {
+		return err
+	}
+
+	return f.extractThumbnail(ctx, skip, filter, outFile, enc)
+}
+
+// extractFrame writes the frame at the given time to 'outFile' using the given
+// encoder, after applying the video filter 'filter'. No filter is applied when
+// 'filter' is empty. The output file is removed when an error occurs.
+func (f *FFmpeg) extractFrame(ctx context.Context, t float64, filter, outFile string, enc encoder) error {
 	os.Remove(outFile)
 
 	args := []string{
+		"-noautorotate",
 		"-ss",
-		SecondsToTime(f.SkipSeconds),
+		formatSeconds(t),
 		"-i",
 		f.Video,
-		"-f",
-		"image2",
 		"-vframes",
 		"1",
 	}
-	if width != 0 {
+	if filter != "" {
 		args = append(args, "-vf")
-		args = append(args, fmt.Sprintf("scale='min(%d\\,iw)':-1", width))
+		args = append(args, filter)
 	}
+	args = append(args, enc.args()...)
 	args = append(args, outFile)
 
-	err := exec.Command(CmdFFmpeg, args...).Run()
+	err := run(ctx, CmdFFmpeg, args...)
 	if err != nil {
+		os.Remove(outFile)
 		return err
 	}
 
@@ -113,50 +239,286 @@ func (f *FFmpeg) CreateThumbnail(width int, outFile string) error {
 // CreateThumbnailSprite creates thumbnails from the video at the given interval,
 // and stitches them together into a single sprite.
 // A thumbnail is generated every 'interval' seconds with a max width of 'width'.
+// An error is returned when 'interval' is less than 1.
+// When FFmpeg.Height is given, every thumbnail is exactly 'width' by
+// FFmpeg.Height pixels, sized using FFmpeg.Fit.
 // The thumbnails are then stitched together into a single image written to 'outFile'.
-func (f *FFmpeg) CreateThumbnailSprite(interval, width int, outFile string) error {
-	tmp, err := ioutil.TempDir(TempDirectory, "thumb")
+// Sprites of images are made from every frame of the image, up to MaxImageFrames.
+// FFmpeg.Label is drawn on each frame when it's set.
+// The returned Sprite describes the size of the sprite, and the position and
+// time of each frame inside it.
+func (f *FFmpeg) CreateThumbnailSprite(ctx context.Context, interval, width int, outFile string) (Sprite, error) {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	return f.createSprite(ctx, interval, width, outFile)
+}
+
+// CreateThumbnailVTT creates a sprite the same way as CreateThumbnailSprite, and
+// writes a WebVTT thumbnail track for the sprite to 'vttFile'.
+// Each cue in the track covers the time range of a single frame, and points to
+// the position of the frame inside the sprite using a "#xywh=" fragment. The
+// 'spriteURL' argument is the location of the sprite as seen by the player.
+func (f *FFmpeg) CreateThumbnailVTT(ctx context.Context, interval, width int, outFile, vttFile, spriteURL string) error {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	sprite, err := f.createSprite(ctx, interval, width, outFile)
 	if err != nil {
 		return err
 	}
-	err = os.MkdirAll(tmp, os.FileMode(0755))
+
+	err = ioutil.WriteFile(vttFile, []byte(VTTTrack(sprite.Frames, interval, spriteURL)), 0644)
 	if err != nil {
+		os.Remove(outFile)
+		os.Remove(vttFile)
 		return err
 	}
+
+	return nil
+}
+
+// createSprite does the work for CreateThumbnailSprite. The sprite is removed
+// when an error occurs.
+func (f *FFmpeg) createSprite(ctx context.Context, interval, width int, outFile string) (Sprite, error) {
+	if err := CheckSpriteInterval(interval); err != nil {
+		return Sprite{}, err
+	}
+	if _, err := ParseColor(f.Background); err != nil {
+		return Sprite{}, err
+	}
+	if _, err := f.encoder(ctx, f.Format); err != nil {
+		return Sprite{}, err
+	}
+	tmp, err := ioutil.TempDir(TempDirectory, "thumb")
+	if err != nil {
+		return Sprite{}, err
+	}
+	defer os.RemoveAll(tmp)
 	os.Remove(outFile)
 
-	filters := []string{
-		fmt.Sprintf("fps=fps=1/%d", interval),
-		fmt.Sprintf("scale='min(%d\\,iw)':-1", width),
+	filter, err := f.frameFilter(ctx, width)
+	if err != nil {
+		return Sprite{}, err
+	}
+	skip, err := f.skipSeconds(ctx)
+	if err != nil {
+		return Sprite{}, err
+	}
+
+	m, err := f.Probe(ctx)
+	if err != nil {
+		return Sprite{}, err
+	}
+	label, err := f.labeler(f.Label)
+	if err != nil {
+		return Sprite{}, err
+	}
+	ptsLabel, err := f.ptsLabelFilter()
+	if err != nil {
+		return Sprite{}, err
+	}
+
+	var files []string
+	var times []float64
+	switch {
+	case m.IsImage():
+		files, times, err = f.extractImageFrames(ctx, joinFilters(filter, ptsLabel), tmp)
+	case f.Selection == SelectScene:
+		times, err = f.sceneTimes(ctx, skip, interval)
+		if err != nil {
+			return Sprite{}, err
+		}
+		files, err = f.extractFrames(ctx, times, filter, label, tmp)
+	default:
+		if f.Seek == SeekAccurate {
+			files, times, err = f.extractIntervalFrames(ctx, skip, interval, joinFilters(filter, ptsLabel), tmp)
+			break
+		}
+		times, err = f.intervalTimes(ctx, skip, interval)
+		if err != nil {
+			return Sprite{}, err
+		}
+		files, err = f.extractFrames(ctx, times, filter, label, tmp)
+	}
+	if err != nil {
+		return Sprite{}, err
+	}
+	files, times = existingFrames(files, times)
+	if len(files) == 0 {
+		return Sprite{}, fmt.Errorf("No frames extracted from video %q.", f.Video)
+	}
+	files = files[:f.Layout.Limit(len(files))]
+	w, h, err := imageSize(files[0])
+	if err != nil {
+		return Sprite{}, err
+	}
+
+	if m.IsImage() || f.Selection == SelectScene {
+		interval = 0
+	}
+	sprite := NewSprite(times, w, h, f.Layout, f.Padding, interval)
+	err = f.stitchFrames(ctx, files, sprite.Frames, outFile)
+	if err != nil {
+		os.Remove(outFile)
+		return Sprite{}, err
+	}
+
+	return sprite, nil
+}
+
+// CheckSpriteInterval returns an error when 'interval' is below 1 second, as
+// frames taken that often would never reach the end of the video.
+func CheckSpriteInterval(interval int) error {
+	if interval < 1 {
+		return fmt.Errorf("Invalid sprite interval %d. Frames must be at least 1 second apart.", interval)
+	}
+	return nil
+}
+
+// extractIntervalFrames writes a frame from the video every 'interval' seconds,
+// starting at 'start' seconds, to the directory 'dir' after applying the video
+// filter 'filter', and returns the frame files along with the time of each frame.
+func (f *FFmpeg) extractIntervalFrames(ctx context.Context, start float64, interval int, filter, dir string) ([]string, []float64, error) {
+	filters := []string{fmt.Sprintf("fps=fps=1/%d", interval)}
+	if filter != "" {
+		filters = append(filters, filter)
 	}
 
-	err = exec.Command(
-		CmdFFmpeg,
+	args := []string{
+		"-noautorotate",
 		"-i",
 		f.Video,
 		"-ss",
-		SecondsToTime(f.SkipSeconds),
-		"-f",
-		"image2",
+		formatSeconds(start),
 		"-vf",
 		strings.Join(filters, ","),
-		tmp+"/frames%04d.jpg",
-	).Run()
+	}
+	args = append(args, frameEncoder.args()...)
+	args = append(args, dir+"/frames%04d.jpg")
+
+	err := run(ctx, CmdFFmpeg, args...)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	err = exec.Command(
-		CmdConvert,
-		tmp+"/*.jpg",
-		"+append",
-		outFile,
-	).Run()
+	files, err := filepath.Glob(dir + "/frames*.jpg")
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+	times := make([]float64, len(files))
+	for i := range files {
+		times[i] = start + float64(i*interval)
 	}
 
-	return nil
+	return files, times, nil
+}
+
+// extractFrames writes the frames at the given times to the directory 'dir'
+// after applying the video filter 'filter', and returns the frame files in
+// the same order as the times. When 'label' isn't nil, the filter it returns
+// for the time of each frame is applied last.
+// Each frame is found with a separate input seek, and up to FFmpeg.Workers
+// frames are extracted at the same time. Frames past the end of the video are
+// not written.
+func (f *FFmpeg) extractFrames(ctx context.Context, times []float64, filter string, label func(float64) string, dir string) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	files := make([]string, len(times))
+	errs := make(chan error, len(times))
+	sem := make(chan bool, f.workers())
+	var wg sync.WaitGroup
+	for i, t := range times {
+		files[i] = fmt.Sprintf("%s/frames%04d.jpg", dir, i+1)
+		wg.Add(1)
+		go func(t float64, file string) {
+			defer wg.Done()
+			sem <- true
+			defer func() {
+				<-sem
+			}()
+			if ctx.Err() != nil {
+				return
+			}
+			frameFilter := filter
+			if label != nil {
+				frameFilter = joinFilters(filter, label(t))
+			}
+			if err := f.extractFrame(ctx, t, frameFilter, file, frameEncoder); err != nil {
+				errs <- err
+				cancel()
+			}
+		}(t, files[i])
+	}
+	wg.Wait()
+	close(errs)
+
+	// The first error is the cause, later ones were canceled because of it.
+	if err, ok := <-errs; ok {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// existingFrames removes the frame files which were not written, along with
+// their times.
+func existingFrames(files []string, times []float64) ([]string, []float64) {
+	keptFiles := make([]string, 0, len(files))
+	keptTimes := make([]float64, 0, len(times))
+	for i, file := range files {
+		if fileExists(file) {
+			keptFiles = append(keptFiles, file)
+			keptTimes = append(keptTimes, times[i])
+		}
+	}
+
+	return keptFiles, keptTimes
+}
+
+// VTTTrack returns a WebVTT thumbnail track for the given sprite frames.
+// Each cue ends where the next one begins, and the last cue is 'interval'
+// seconds long.
+func VTTTrack(frames []SpriteFrame, interval int, spriteURL string) string {
+	buff := bytes.Buffer{}
+	buff.WriteString("WEBVTT\n")
+	for i, frame := range frames {
+		end := frame.Time + float64(interval)
+		if i < len(frames)-1 {
+			end = frames[i+1].Time
+		}
+		buff.WriteString(fmt.Sprintf(
+			"\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n",
+			FormatTime(frame.Time),
+			FormatTime(end),
+			spriteURL,
+			frame.X,
+			frame.Y,
+			frame.Width,
+			frame.Height))
+	}
+
+	return buff.String()
+}
+
+// imageSize returns the width and height of the given image file.
+func imageSize(file string) (int, int, error) {
+	fin, err := os.Open(file)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer fin.Close()
+
+	conf, _, err := image.DecodeConfig(fin)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return conf.Width, conf.Height, nil
 }
 
 // SecondsToTime converts seconds into "00:00:00" format.
@@ -171,3 +533,15 @@ func SecondsToTime(secs int) string {
 
 	return fmt.Sprintf("%.2d:%.2d:%.2d", hours, minutes, seconds)
 }
+
+// formatSeconds converts seconds into a string accepted by the ffmpeg -ss option.
+func formatSeconds(secs float64) string {
+	return strconv.FormatFloat(secs, 'f', 3, 64)
+}
+
+// scaleFilter returns the ffmpeg filter which scales frames down to 'width'
+// pixels wide while keeping the aspect ratio. Frames narrower than 'width'
+// are left alone.
+func scaleFilter(width int) string {
+	return fmt.Sprintf("scale='min(%d\\,iw)':-1", width)
+}
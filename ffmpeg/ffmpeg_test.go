@@ -0,0 +1,77 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// benchVideoLength is the length in seconds of the video generated for the
+// benchmarks.
+const benchVideoLength = 120
+
+// benchVideo generates a test video with ffmpeg, and returns its path.
+// The benchmark is skipped when ffmpeg is not installed.
+func benchVideo(b *testing.B) string {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		b.Skip("ffmpeg is not installed")
+	}
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		b.Skip("ffprobe is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "thumb")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	video := filepath.Join(dir, "test.mp4")
+	err = run(
+		context.Background(),
+		"ffmpeg",
+		"-f",
+		"lavfi",
+		"-i",
+		fmt.Sprintf("testsrc=duration=%d:size=640x360:rate=30", benchVideoLength),
+		"-g",
+		"60",
+		"-pix_fmt",
+		"yuv420p",
+		video)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return video
+}
+
+// benchmarkSprite creates a 30 frame sprite from a generated test video using
+// the given seek strategy.
+func benchmarkSprite(b *testing.B, seek string) {
+	video := benchVideo(b)
+	out := filepath.Join(filepath.Dir(video), "sprite.jpg")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f := New(video)
+		f.Seek = seek
+		if _, err := f.CreateThumbnailSprite(context.Background(), benchVideoLength/30, 180, out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSpriteFast(b *testing.B) {
+	benchmarkSprite(b, SeekFast)
+}
+
+func BenchmarkSpriteAccurate(b *testing.B) {
+	benchmarkSprite(b, SeekAccurate)
+}
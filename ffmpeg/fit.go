@@ -0,0 +1,89 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Fit modes used when both the width and the height of a thumbnail are given.
+const (
+	// FitContain scales the frame to fit inside the thumbnail, and pads the
+	// remaining space with FFmpeg.PadColor.
+	FitContain = "contain"
+	// FitCover scales the frame to cover the thumbnail, and crops whatever
+	// falls outside of it around the center.
+	FitCover = "cover"
+	// FitStretch scales the frame to the thumbnail size, ignoring the aspect ratio.
+	FitStretch = "stretch"
+)
+
+// MaxSize is the largest width or height allowed for thumbnails and frames.
+const MaxSize = 8192
+
+// CheckSize returns an error when 'width' or 'height' is negative or larger
+// than MaxSize. A size of 0 leaves that side to the frame.
+func CheckSize(width, height int) error {
+	if width < 0 || width > MaxSize {
+		return fmt.Errorf("Invalid width %d. Use 0 to %d pixels.", width, MaxSize)
+	}
+	if height < 0 || height > MaxSize {
+		return fmt.Errorf("Invalid height %d. Use 0 to %d pixels.", height, MaxSize)
+	}
+	return nil
+}
+
+// ParseFit validates a fit mode, and returns it in its normalized form.
+// An empty string selects FitContain.
+func ParseFit(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "", FitContain:
+		return FitContain, nil
+	case FitCover, FitStretch:
+		return s, nil
+	}
+
+	return "", fmt.Errorf("Invalid fit mode %q.", s)
+}
+
+// sizeFilter returns the video filter which sizes thumbnails to 'width' by
+// FFmpeg.Height pixels using FFmpeg.Fit.
+// When only one of the width and height is given, frames are scaled down to
+// that size keeping their aspect ratio, and frames which are already smaller
+// are left alone. An empty string is returned when neither is given.
+func (f *FFmpeg) sizeFilter(width int) (string, error) {
+	height := f.Height
+	if err := CheckSize(width, height); err != nil {
+		return "", err
+	}
+	switch {
+	case width == 0 && height == 0:
+		return "", nil
+	case height == 0:
+		return scaleFilter(width), nil
+	case width == 0:
+		return fmt.Sprintf("scale=-1:'min(%d\\,ih)'", height), nil
+	}
+
+	fit, err := ParseFit(f.Fit)
+	if err != nil {
+		return "", err
+	}
+
+	switch fit {
+	case FitCover:
+		return fmt.Sprintf(
+			"scale=%d:%d:force_original_aspect_ratio=increase,crop=%d:%d,setsar=1",
+			width, height, width, height), nil
+	case FitStretch:
+		return fmt.Sprintf("scale=%d:%d,setsar=1", width, height), nil
+	}
+
+	c, err := ParseColor(f.PadColor)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(
+		"scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2:color=%s,setsar=1",
+		width, height, width, height, hexColor(c)), nil
+}
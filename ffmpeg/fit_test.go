@@ -0,0 +1,62 @@
+package ffmpeg
+
+import "testing"
+
+func TestCheckSize(t *testing.T) {
+	tests := []struct {
+		width, height int
+		valid         bool
+	}{
+		{0, 0, true},
+		{320, 180, true},
+		{MaxSize, MaxSize, true},
+		{-1, 0, false},
+		{0, -1, false},
+		{MaxSize + 1, 180, false},
+		{320, MaxSize + 1, false},
+		{16000, 16000, false},
+	}
+
+	for _, test := range tests {
+		err := CheckSize(test.width, test.height)
+		if test.valid && err != nil {
+			t.Errorf("CheckSize(%d, %d) error: %s", test.width, test.height, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("CheckSize(%d, %d) did not return an error", test.width, test.height)
+		}
+	}
+}
+
+func TestSizeFilter(t *testing.T) {
+	tests := []struct {
+		width, height int
+		fit           string
+		want          string
+	}{
+		{0, 0, "", ""},
+		{320, 0, "", "scale='min(320\\,iw)':-1"},
+		{0, 180, "", "scale=-1:'min(180\\,ih)'"},
+		{320, 180, FitStretch, "scale=320:180,setsar=1"},
+		{320, 180, FitCover, "scale=320:180:force_original_aspect_ratio=increase,crop=320:180,setsar=1"},
+	}
+
+	for _, test := range tests {
+		f := New("video.mp4")
+		f.Height = test.height
+		f.Fit = test.fit
+		got, err := f.sizeFilter(test.width)
+		if err != nil {
+			t.Errorf("sizeFilter(%d) with height %d error: %s", test.width, test.height, err)
+		} else if got != test.want {
+			t.Errorf("sizeFilter(%d) with height %d = %q, want %q", test.width, test.height, got, test.want)
+		}
+	}
+
+	f := New("video.mp4")
+	f.Height = 16000
+	f.Fit = FitStretch
+	if _, err := f.sizeFilter(16000); err == nil {
+		t.Error("sizeFilter(16000) with height 16000 did not return an error")
+	}
+}
@@ -0,0 +1,199 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Format is an image format thumbnails may be written in.
+type Format string
+
+// Supported image formats.
+const (
+	FormatJPEG Format = "jpeg"
+	FormatPNG  Format = "png"
+	FormatWebP Format = "webp"
+	FormatAVIF Format = "avif"
+	FormatGIF  Format = "gif"
+)
+
+// ErrUnsupportedFormat is returned when the local ffmpeg build cannot write
+// the requested format.
+var ErrUnsupportedFormat = errors.New("The image format is not supported by this build of ffmpeg.")
+
+// formatEncoders lists the ffmpeg encoders able to write each format, in
+// order of preference.
+var formatEncoders = map[Format][]string{
+	FormatJPEG: {"mjpeg"},
+	FormatPNG:  {"png"},
+	FormatWebP: {"libwebp"},
+	FormatAVIF: {"libaom-av1", "libsvtav1"},
+	FormatGIF:  {"gif"},
+}
+
+// frameEncoder is used to write the frames which are stitched into sprites
+// and scored when choosing the best thumbnail.
+var frameEncoder = encoder{format: FormatJPEG, codec: "mjpeg", quality: 95}
+
+var (
+	// encodersMutex protects encoderNames.
+	encodersMutex sync.Mutex
+	// encoderNames caches the names of the encoders available to ffmpeg.
+	encoderNames map[string]bool
+)
+
+// ParseFormat converts a string into a Format.
+// An empty string selects FormatJPEG.
+func ParseFormat(s string) (Format, error) {
+	s = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s), "."))
+	switch s {
+	case "", "jpg", "jpeg":
+		return FormatJPEG, nil
+	case "png":
+		return FormatPNG, nil
+	case "webp":
+		return FormatWebP, nil
+	case "avif":
+		return FormatAVIF, nil
+	case "gif":
+		return FormatGIF, nil
+	}
+
+	return "", fmt.Errorf("Invalid image format %q.", s)
+}
+
+// FormatFromFile returns the format matching the extension of the given file
+// name. FormatJPEG is returned when the extension is not recognized.
+func FormatFromFile(file string) Format {
+	format, err := ParseFormat(filepath.Ext(file))
+	if err != nil {
+		return FormatJPEG
+	}
+	return format
+}
+
+// Ext returns the file extension used by the format, including the dot.
+func (f Format) Ext() string {
+	if f == FormatJPEG || f == "" {
+		return ".jpg"
+	}
+	return "." + string(f)
+}
+
+// MimeType returns the mime type of the format.
+func (f Format) MimeType() string {
+	if f == "" {
+		return "image/jpeg"
+	}
+	return "image/" + string(f)
+}
+
+// Decodable returns whether images in the format can be read by the standard
+// image package, which is needed to find placeholders and colors of thumbnails.
+func (f Format) Decodable() bool {
+	return f == FormatJPEG || f == FormatPNG || f == FormatGIF || f == ""
+}
+
+// encoder describes how ffmpeg writes an image in a given format.
+type encoder struct {
+	format Format
+	// codec is the name of the ffmpeg encoder.
+	codec string
+	// quality is the image quality from 1 to 100, or 0 for the encoder default.
+	quality int
+}
+
+// args returns the ffmpeg output arguments used to write images.
+func (e encoder) args() []string {
+	args := []string{"-c:v", e.codec}
+	if e.quality > 0 {
+		switch e.format {
+		case FormatJPEG:
+			// The mjpeg encoder uses a scale from 2 (best) to 31 (worst).
+			args = append(args, "-q:v", strconv.Itoa(2+(100-e.quality)*29/99))
+		case FormatWebP:
+			args = append(args, "-quality", strconv.Itoa(e.quality))
+		case FormatAVIF:
+			// The AV1 encoders use a scale from 0 (best) to 63 (worst).
+			args = append(args, "-crf", strconv.Itoa((100-e.quality)*63/100), "-b:v", "0")
+		}
+	}
+
+	if e.format == FormatAVIF {
+		return append(args, "-still-picture", "1", "-f", "avif")
+	}
+	return append(args, "-f", "image2")
+}
+
+// encoder returns the encoder used to write images in the given format.
+// An error wrapping ErrUnsupportedFormat is returned when the local ffmpeg
+// build has no encoder for the format.
+func (f *FFmpeg) encoder(ctx context.Context, format Format) (encoder, error) {
+	if format == "" {
+		format = FormatJPEG
+	}
+	codecs, ok := formatEncoders[format]
+	if !ok {
+		return encoder{}, fmt.Errorf("Invalid image format %q.", format)
+	}
+
+	names, err := availableEncoders(ctx)
+	if err != nil {
+		return encoder{}, err
+	}
+	for _, codec := range codecs {
+		if names[codec] {
+			return encoder{format: format, codec: codec, quality: f.Quality}, nil
+		}
+	}
+
+	return encoder{}, fmt.Errorf("%w Cannot write %s images without one of the encoders %s.",
+		ErrUnsupportedFormat, format, strings.Join(codecs, ", "))
+}
+
+// availableEncoders returns the names of the encoders available to ffmpeg.
+// The list is read once and cached for subsequent calls.
+func availableEncoders(ctx context.Context) (map[string]bool, error) {
+	encodersMutex.Lock()
+	defer encodersMutex.Unlock()
+	if encoderNames != nil {
+		return encoderNames, nil
+	}
+
+	output, err := output(ctx, CmdFFmpeg, "-hide_banner", "-encoders")
+	if err != nil {
+		return nil, err
+	}
+	encoderNames = parseEncoders(string(output))
+
+	return encoderNames, nil
+}
+
+// parseEncoders returns the encoder names from the output of "ffmpeg -encoders".
+// Encoders are listed after a "------" separator, one per line, eg:
+//
+//	V....D libwebp              libwebp WebP image (codec webp)
+func parseEncoders(output string) map[string]bool {
+	names := map[string]bool{}
+	listing := false
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if strings.HasPrefix(fields[0], "---") {
+			listing = true
+			continue
+		}
+		if listing && len(fields) > 1 {
+			names[fields[1]] = true
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,165 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Output of "ffmpeg -hide_banner -encoders", shortened.
+const (
+	encodersFull = `Encoders:
+ V..... = Video
+ A..... = Audio
+ S..... = Subtitle
+ .F.... = Frame-level multithreading
+ ..S... = Slice-level multithreading
+ ...X.. = Codec is experimental
+ ....B. = Supports draw_horiz_band
+ .....D = Supports direct rendering method 1
+ ------
+ V....D gif                  GIF (Graphics Interchange Format)
+ V..... libaom-av1           libaom AV1 (codec av1)
+ V....D libsvtav1            SVT-AV1(Scalable Video Technology for AV1) encoder (codec av1)
+ VFS..D mjpeg                MJPEG (Motion JPEG)
+ VF...D png                  PNG (Portable Network Graphics) image
+ V....D libwebp_anim         libwebp WebP image (codec webp)
+ V....D libwebp              libwebp WebP image (codec webp)
+ A....D aac                  AAC (Advanced Audio Coding)
+`
+	encodersMinimal = `Encoders:
+ V..... = Video
+ A..... = Audio
+ ------
+ V....D gif                  GIF (Graphics Interchange Format)
+ VFS..D mjpeg                MJPEG (Motion JPEG)
+ VF...D png                  PNG (Portable Network Graphics) image
+ V....D webp_anim            WebP animation (codec webp)
+ A....D aac                  AAC (Advanced Audio Coding)
+`
+)
+
+func TestParseEncoders(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		present []string
+		missing []string
+	}{
+		{"full", encodersFull, []string{"gif", "libaom-av1", "libsvtav1", "mjpeg", "png", "libwebp", "aac"}, []string{"V.....", "Video", "webp_anim"}},
+		{"minimal", encodersMinimal, []string{"gif", "mjpeg", "png", "aac"}, []string{"libwebp", "libaom-av1", "libsvtav1", "Video"}},
+		{"empty", "", nil, []string{"mjpeg"}},
+		{"no separator", " V....D mjpeg  MJPEG (Motion JPEG)\n", nil, []string{"mjpeg"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			names := parseEncoders(test.output)
+			for _, name := range test.present {
+				if !names[name] {
+					t.Errorf("parseEncoders() is missing %q", name)
+				}
+			}
+			for _, name := range test.missing {
+				if names[name] {
+					t.Errorf("parseEncoders() includes %q", name)
+				}
+			}
+		})
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	defer func(names map[string]bool) { encoderNames = names }(encoderNames)
+
+	tests := []struct {
+		output string
+		format Format
+		codec  string
+	}{
+		{encodersFull, "", "mjpeg"},
+		{encodersFull, FormatJPEG, "mjpeg"},
+		{encodersFull, FormatPNG, "png"},
+		{encodersFull, FormatWebP, "libwebp"},
+		{encodersFull, FormatAVIF, "libaom-av1"},
+		{encodersFull, FormatGIF, "gif"},
+		{encodersMinimal, FormatJPEG, "mjpeg"},
+		{encodersMinimal, FormatWebP, ""},
+		{encodersMinimal, FormatAVIF, ""},
+	}
+
+	for _, test := range tests {
+		encoderNames = parseEncoders(test.output)
+		f := New("video.mp4")
+		f.Quality = 80
+		enc, err := f.encoder(context.Background(), test.format)
+		if test.codec == "" {
+			if !errors.Is(err, ErrUnsupportedFormat) {
+				t.Errorf("encoder(%q) error = %v, want ErrUnsupportedFormat", test.format, err)
+			}
+			continue
+		}
+		if err != nil || enc.codec != test.codec || enc.quality != 80 {
+			t.Errorf("encoder(%q) = %+v, %v, want codec %s with quality 80", test.format, enc, err, test.codec)
+		}
+	}
+
+	encoderNames = parseEncoders(encodersFull)
+	if _, err := New("video.mp4").encoder(context.Background(), "bmp"); err == nil || errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("encoder(bmp) error = %v, want an invalid format error", err)
+	}
+}
+
+func TestEncoderArgs(t *testing.T) {
+	tests := []struct {
+		enc  encoder
+		want string
+	}{
+		{encoder{format: FormatJPEG, codec: "mjpeg"}, "-c:v mjpeg -f image2"},
+		{encoder{format: FormatJPEG, codec: "mjpeg", quality: 100}, "-c:v mjpeg -q:v 2 -f image2"},
+		{encoder{format: FormatJPEG, codec: "mjpeg", quality: 75}, "-c:v mjpeg -q:v 9 -f image2"},
+		{encoder{format: FormatJPEG, codec: "mjpeg", quality: 1}, "-c:v mjpeg -q:v 31 -f image2"},
+		{encoder{format: FormatPNG, codec: "png"}, "-c:v png -f image2"},
+		{encoder{format: FormatPNG, codec: "png", quality: 50}, "-c:v png -f image2"},
+		{encoder{format: FormatWebP, codec: "libwebp"}, "-c:v libwebp -f image2"},
+		{encoder{format: FormatWebP, codec: "libwebp", quality: 80}, "-c:v libwebp -quality 80 -f image2"},
+		{encoder{format: FormatAVIF, codec: "libaom-av1"}, "-c:v libaom-av1 -still-picture 1 -f avif"},
+		{encoder{format: FormatAVIF, codec: "libaom-av1", quality: 100}, "-c:v libaom-av1 -crf 0 -b:v 0 -still-picture 1 -f avif"},
+		{encoder{format: FormatAVIF, codec: "libsvtav1", quality: 50}, "-c:v libsvtav1 -crf 31 -b:v 0 -still-picture 1 -f avif"},
+		{encoder{format: FormatAVIF, codec: "libaom-av1", quality: 1}, "-c:v libaom-av1 -crf 62 -b:v 0 -still-picture 1 -f avif"},
+	}
+
+	for _, test := range tests {
+		if got := strings.Join(test.enc.args(), " "); got != test.want {
+			t.Errorf("%+v args() = %q, want %q", test.enc, got, test.want)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Format
+	}{
+		{"", FormatJPEG},
+		{"jpg", FormatJPEG},
+		{".JPEG", FormatJPEG},
+		{" png ", FormatPNG},
+		{"webp", FormatWebP},
+		{"avif", FormatAVIF},
+		{"gif", FormatGIF},
+	}
+
+	for _, test := range tests {
+		if got, err := ParseFormat(test.in); err != nil || got != test.want {
+			t.Errorf("ParseFormat(%q) = %q, %v, want %q", test.in, got, err, test.want)
+		}
+	}
+	if _, err := ParseFormat("bmp"); err == nil {
+		t.Error("ParseFormat(bmp) did not return an error")
+	}
+	if got := FormatFromFile("thumb.webp"); got != FormatWebP {
+		t.Errorf("FormatFromFile(thumb.webp) = %q, want webp", got)
+	}
+}
@@ -0,0 +1,214 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultFrames is the number of frames chosen by FrameTimes when 'count' is 0.
+const DefaultFrames = 5
+
+// Still is a single frame written by CreateFrames.
+type Still struct {
+	// Time is the position of the frame in the video, in seconds.
+	Time float64
+	// File is the file the frame was written to.
+	File string
+}
+
+// FrameTimes returns 'count' timestamps evenly spaced between FFmpeg.Skip and
+// the end of the video. Each timestamp is in the middle of an equal share of
+// the video, which avoids the very first and last frames.
+func (f *FFmpeg) FrameTimes(ctx context.Context, count int) ([]Timestamp, error) {
+	length, err := f.Length(ctx)
+	if err != nil {
+		return nil, err
+	}
+	start, err := f.skipSeconds(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return EvenTimes(start, length, count), nil
+}
+
+// EvenTimes returns 'count' timestamps evenly spaced between 'start' and 'end'
+// seconds, each in the middle of an equal share of the time between them.
+// DefaultFrames timestamps are returned when 'count' is 0.
+func EvenTimes(start, end float64, count int) []Timestamp {
+	if count < 1 {
+		count = DefaultFrames
+	}
+
+	share := (end - start) / float64(count)
+	times := make([]Timestamp, count)
+	for i := range times {
+		times[i] = Seconds(start + (float64(i)+0.5)*share)
+	}
+
+	return times
+}
+
+// CreateFrames writes the frames at the given timestamps to separate images,
+// and returns the frames in the same order as 'times'.
+// The video is decoded once no matter how many frames are written. The
+// frames are sized the same way as CreateThumbnail and written in
+// FFmpeg.Format.
+// The 'outFile' argument is a template for the image file names. "{index}" is
+// replaced by the position of the frame in 'times', starting at 1, and "{time}"
+// by the position of the frame in the video in seconds. When the template has
+// neither, "-{index}" is added before the file extension. The images are
+// removed when an error occurs.
+func (f *FFmpeg) CreateFrames(ctx context.Context, times []Timestamp, width int, outFile string) ([]Still, error) {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	if len(times) == 0 {
+		return nil, errors.New("No frame timestamps given.")
+	}
+	enc, err := f.encoder(ctx, f.Format)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := f.frameFilter(ctx, width)
+	if err != nil {
+		return nil, err
+	}
+	m, err := f.Probe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if m.IsImage() && !m.IsAnimated() && len(times) > 1 {
+		return nil, fmt.Errorf("Cannot take %d frames from still image %q.", len(times), f.Video)
+	}
+
+	stills := make([]Still, len(times))
+	for i, t := range times {
+		secs, err := t.Seconds(m.Duration, m.FrameRate)
+		if err != nil {
+			return nil, err
+		}
+		stills[i] = Still{Time: secs, File: FrameFile(outFile, i+1, secs)}
+	}
+	start, err := checkFrameSpacing(stills, m.FrameRate)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{
+		"-noautorotate",
+		"-ss",
+		formatSeconds(start),
+		"-i",
+		f.Video,
+		"-an",
+		"-sn",
+		"-filter_complex",
+		framesGraph(stills, start, filter),
+	}
+	for i, still := range stills {
+		os.Remove(still.File)
+		args = append(args, "-map", fmt.Sprintf("[out%d]", i), "-frames:v", "1")
+		args = append(args, enc.args()...)
+		args = append(args, still.File)
+	}
+
+	err = run(ctx, CmdFFmpeg, args...)
+	if err == nil {
+		for _, still := range stills {
+			if !fileExists(still.File) {
+				err = fmt.Errorf("No frame found at %s in video %q.", FormatTime(still.Time), f.Video)
+				break
+			}
+		}
+	}
+	if err != nil {
+		for _, still := range stills {
+			os.Remove(still.File)
+		}
+		return nil, err
+	}
+
+	return stills, nil
+}
+
+// checkFrameSpacing makes sure no two stills fall on the same frame of a video
+// with the given frame rate, and returns the time of the earliest still.
+func checkFrameSpacing(stills []Still, frameRate float64) (float64, error) {
+	sorted := make([]float64, len(stills))
+	for i, still := range stills {
+		sorted[i] = still.Time
+	}
+	sort.Float64s(sorted)
+
+	// Stills exactly a frame apart are allowed, despite rounding errors.
+	spacing := 0.0
+	if frameRate > 0 {
+		spacing = 1/frameRate - 1e-9
+	}
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i]-sorted[i-1] < spacing || sorted[i] == sorted[i-1] {
+			return 0, fmt.Errorf(
+				"Timestamps %s and %s fall on the same frame.",
+				FormatTime(sorted[i-1]),
+				FormatTime(sorted[i]))
+		}
+	}
+
+	return sorted[0], nil
+}
+
+// framesGraph returns the ffmpeg filter graph which sends the frame at the time
+// of each still to its own output, labeled "[out0]", "[out1]", etc, after
+// applying the video filter 'filter'. The input is expected to begin at
+// 'start' seconds.
+// The select filter picks the first frame at or after the time of each still,
+// and the value of its expression is the number of the output the frame is
+// sent to.
+func framesGraph(stills []Still, start float64, filter string) string {
+	if filter == "" {
+		filter = "null"
+	}
+
+	exprs := make([]string, len(stills))
+	labels := make([]string, len(stills))
+	chains := make([]string, len(stills))
+	for i, still := range stills {
+		t := formatSeconds(still.Time - start)
+		exprs[i] = fmt.Sprintf("%d*gte(t\\,%s)*not(gte(prev_t\\,%s))", i+1, t, t)
+		labels[i] = fmt.Sprintf("[s%d]", i)
+		chains[i] = fmt.Sprintf("[s%d]%s[out%d]", i, filter, i)
+	}
+
+	return fmt.Sprintf(
+		"[0:v]select='%s':outputs=%d%s;%s",
+		strings.Join(exprs, "+"),
+		len(stills),
+		strings.Join(labels, ""),
+		strings.Join(chains, ";"))
+}
+
+// FrameFile expands the file name template used by CreateFrames for the frame
+// with the given index at 'secs' seconds into the video.
+func FrameFile(template string, index int, secs float64) string {
+	if !strings.Contains(template, "{index}") && !strings.Contains(template, "{time}") {
+		ext := filepath.Ext(template)
+		template = strings.TrimSuffix(template, ext) + "-{index}" + ext
+	}
+	template = strings.Replace(template, "{index}", fmt.Sprintf("%02d", index), -1)
+	template = strings.Replace(template, "{time}", strconv.FormatFloat(secs, 'f', 3, 64), -1)
+
+	return template
+}
+
+// fileExists returns whether the given file exists.
+func fileExists(file string) bool {
+	_, err := os.Stat(file)
+	return err == nil
+}
@@ -0,0 +1,68 @@
+package ffmpeg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckFrameSpacing(t *testing.T) {
+	// 0.4 seconds at 25fps split into 10 shares, which are a frame apart give
+	// or take rounding errors.
+	stills := make([]Still, 10)
+	for i := range stills {
+		stills[i].Time = (float64(i) + 0.5) * (0.4 / 10)
+	}
+	start, err := checkFrameSpacing(stills, 25)
+	if err != nil {
+		t.Fatalf("checkFrameSpacing error: %s", err)
+	}
+	if start != stills[0].Time {
+		t.Errorf("start = %v, want %v", start, stills[0].Time)
+	}
+
+	stills = []Still{{Time: 1}, {Time: 1.02}}
+	if _, err := checkFrameSpacing(stills, 25); err == nil {
+		t.Error("checkFrameSpacing did not return an error for stills on the same frame")
+	}
+}
+
+func TestEvenTimes(t *testing.T) {
+	times := EvenTimes(10, 50, 4)
+	want := []float64{15, 25, 35, 45}
+	if len(times) != len(want) {
+		t.Fatalf("EvenTimes() returned %d times, want %d", len(times), len(want))
+	}
+	for i, ts := range times {
+		secs, err := ts.Seconds(60, 25)
+		if err != nil || secs != want[i] {
+			t.Errorf("times[%d] = %v, %v, want %v", i, secs, err, want[i])
+		}
+	}
+
+	if n := len(EvenTimes(0, 60, 0)); n != DefaultFrames {
+		t.Errorf("EvenTimes() with count 0 returned %d times, want %d", n, DefaultFrames)
+	}
+}
+
+func TestCandidateTimes(t *testing.T) {
+	tests := []struct {
+		length, skip  float64
+		count, window int
+		want          []float64
+	}{
+		{100, 0, 3, 0, []float64{5, 50, 95}},
+		{100, 40, 3, 10, []float64{30, 40, 50}},
+		{100, 5, 3, 10, []float64{0, 7.5, 15}},
+		{100, 0, 1, 0, []float64{5}},
+	}
+
+	for _, test := range tests {
+		got := CandidateTimes(test.length, test.skip, test.count, test.window)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("CandidateTimes(%v, %v, %d, %d) = %v, want %v", test.length, test.skip, test.count, test.window, got, test.want)
+		}
+	}
+	if n := len(CandidateTimes(100, 0, 0, 0)); n != DefaultCandidates {
+		t.Errorf("CandidateTimes() with count 0 returned %d times, want %d", n, DefaultCandidates)
+	}
+}
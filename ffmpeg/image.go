@@ -0,0 +1,236 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// MaxImageFrames is the largest number of frames put in a sprite of an
+// animated image. Images with more frames are thinned out evenly.
+const MaxImageFrames = 100
+
+// stillImageFormats are the ffprobe formats of files holding a single image.
+var stillImageFormats = []string{
+	"image2",
+	"jpeg_pipe",
+	"png_pipe",
+	"webp_pipe",
+	"bmp_pipe",
+	"tiff_pipe",
+}
+
+// animatedImageFormats are the ffprobe formats of animated images.
+var animatedImageFormats = []string{
+	"gif",
+	"apng",
+}
+
+// heifBrands are the major brands of HEIF files, which ffprobe reports as
+// mov files.
+var heifBrands = []string{
+	"heic",
+	"heix",
+	"mif1",
+	"msf1",
+	"avif",
+}
+
+// exifOrientationFilters are the video filters which turn an image upright
+// for each EXIF orientation value.
+var exifOrientationFilters = map[int]string{
+	2: "hflip",
+	3: "hflip,vflip",
+	4: "vflip",
+	5: "transpose=cclock_flip",
+	6: "transpose=clock",
+	7: "transpose=clock_flip",
+	8: "transpose=cclock",
+}
+
+// IsImage returns whether the file is a still or animated image rather than a video.
+func (m *Metadata) IsImage() bool {
+	return m.isStillImage() || contains(animatedImageFormats, m.Format)
+}
+
+// IsAnimated returns whether the file is an image with more than one frame.
+func (m *Metadata) IsAnimated() bool {
+	return m.IsImage() && m.Duration > 0
+}
+
+// isStillImage returns whether the file holds a single image.
+func (m *Metadata) isStillImage() bool {
+	return contains(stillImageFormats, m.Format) || contains(heifBrands, m.MajorBrand)
+}
+
+// contains returns whether 'list' contains 'value'.
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// imageOrientFilter returns the video filter which turns the image upright
+// using the orientation stored in its EXIF data. An empty string is returned
+// when the image has no orientation, or is already upright.
+func (f *FFmpeg) imageOrientFilter() string {
+	orientation := exifOrientation(f.Video)
+	filter := exifOrientationFilters[orientation]
+	if filter != "" {
+		f.logf("Applying EXIF orientation %d to image %q.", orientation, f.Video)
+	}
+
+	return filter
+}
+
+// exifOrientation returns the EXIF orientation of the JPEG image 'file', which
+// is a value between 1 and 8. 0 is returned when the file is not a JPEG, or
+// does not have an orientation.
+func exifOrientation(file string) int {
+	fin, err := os.Open(file)
+	if err != nil {
+		return 0
+	}
+	defer fin.Close()
+
+	exif, err := jpegExif(bufio.NewReader(fin))
+	if err != nil || exif == nil {
+		return 0
+	}
+
+	return tiffOrientation(exif)
+}
+
+// jpegExif returns the EXIF data of the JPEG image read from 'r', or nil when
+// the image does not have any.
+func jpegExif(r *bufio.Reader) ([]byte, error) {
+	soi := make([]byte, 2)
+	if _, err := io.ReadFull(r, soi); err != nil {
+		return nil, err
+	}
+	if soi[0] != 0xff || soi[1] != 0xd8 {
+		return nil, errors.New("Not a JPEG image.")
+	}
+
+	for {
+		marker := make([]byte, 4)
+		if _, err := io.ReadFull(r, marker); err != nil {
+			return nil, err
+		}
+		if marker[0] != 0xff {
+			return nil, errors.New("Invalid JPEG marker.")
+		}
+		// The metadata segments all come before the start of the image data.
+		if marker[1] == 0xda || marker[1] == 0xd9 {
+			return nil, nil
+		}
+
+		size := int(binary.BigEndian.Uint16(marker[2:])) - 2
+		if size < 0 {
+			return nil, errors.New("Invalid JPEG segment size.")
+		}
+		segment := make([]byte, size)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return nil, err
+		}
+		if marker[1] == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:], nil
+		}
+	}
+}
+
+// tiffOrientation returns the value of the orientation tag in the first IFD
+// of the given TIFF structured EXIF data, or 0 when it's missing.
+func tiffOrientation(exif []byte) int {
+	if len(exif) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(exif[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifd := int(order.Uint32(exif[4:]))
+	if ifd+2 > len(exif) {
+		return 0
+	}
+	entries := int(order.Uint16(exif[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(exif) {
+			return 0
+		}
+		if order.Uint16(exif[entry:]) == 0x0112 {
+			orientation := int(order.Uint16(exif[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 0
+			}
+			return orientation
+		}
+	}
+
+	return 0
+}
+
+// extractImageFrames writes every frame of the animated image to the directory
+// 'dir' after applying the video filter 'filter', and returns the frame files
+// along with the time of each frame. No more than MaxImageFrames frames are
+// returned, chosen evenly from the image.
+func (f *FFmpeg) extractImageFrames(ctx context.Context, filter, dir string) ([]string, []float64, error) {
+	length, err := f.Length(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	args := []string{
+		"-noautorotate",
+		"-i",
+		f.Video,
+		"-vsync",
+		"0",
+	}
+	if filter != "" {
+		args = append(args, "-vf", filter)
+	}
+	args = append(args, frameEncoder.args()...)
+	args = append(args, dir+"/frames%04d.jpg")
+
+	err = run(ctx, CmdFFmpeg, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	all, err := filepath.Glob(dir + "/frames*.jpg")
+	if err != nil {
+		return nil, nil, err
+	}
+	count := len(all)
+	if count > MaxImageFrames {
+		count = MaxImageFrames
+	}
+
+	// The frames of an animated image may have different delays, so the
+	// times are an estimate.
+	files := make([]string, count)
+	times := make([]float64, count)
+	for i := range files {
+		n := i * len(all) / count
+		files[i] = all[n]
+		times[i] = length * float64(n) / float64(len(all))
+	}
+
+	return files, times, nil
+}
@@ -0,0 +1,87 @@
+package ffmpeg
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// jpegWithOrientation returns the start of a JPEG image with an EXIF segment
+// holding the given orientation, using the given byte order.
+func jpegWithOrientation(orientation int, order binary.ByteOrder) []byte {
+	tiff := make([]byte, 26)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], 0x0112)
+	order.PutUint16(tiff[12:], 3)
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], uint16(orientation))
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	data := []byte{0xff, 0xd8, 0xff, 0xe0, 0x00, 0x04, 0x00, 0x00, 0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(data[10:], uint16(len(segment)+2))
+	data = append(data, segment...)
+	return append(data, 0xff, 0xda, 0x00, 0x02)
+}
+
+func TestExifOrientation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thumb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"rotated.jpg", jpegWithOrientation(6, binary.BigEndian), 6},
+		{"mirrored.jpg", jpegWithOrientation(2, binary.LittleEndian), 2},
+		{"invalid.jpg", jpegWithOrientation(9, binary.BigEndian), 0},
+		{"plain.jpg", []byte{0xff, 0xd8, 0xff, 0xda, 0x00, 0x02}, 0},
+		{"image.png", []byte("\x89PNG\r\n\x1a\n"), 0},
+	}
+
+	for _, test := range tests {
+		file := filepath.Join(dir, test.name)
+		if err := ioutil.WriteFile(file, test.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got := exifOrientation(file); got != test.want {
+			t.Errorf("exifOrientation(%s) = %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMetadataIsImage(t *testing.T) {
+	tests := []struct {
+		output   string
+		image    bool
+		animated bool
+	}{
+		{`{"format": {"format_name": "jpeg_pipe", "duration": "0.040000"}, "streams": [{"codec_type": "video"}]}`, true, false},
+		{`{"format": {"format_name": "gif", "duration": "2.500000"}, "streams": [{"codec_type": "video"}]}`, true, true},
+		{`{"format": {"format_name": "mov,mp4,m4a,3gp,3g2,mj2", "tags": {"major_brand": "heic"}}, "streams": [{"codec_type": "video"}]}`, true, false},
+		{`{"format": {"format_name": "mov,mp4,m4a,3gp,3g2,mj2", "duration": "60.0", "tags": {"major_brand": "isom"}}, "streams": [{"codec_type": "video"}]}`, false, false},
+	}
+
+	for _, test := range tests {
+		m, err := parseProbeOutput([]byte(test.output))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.IsImage() != test.image || m.IsAnimated() != test.animated {
+			t.Errorf("%s: IsImage() = %v and IsAnimated() = %v, want %v and %v",
+				m.Format, m.IsImage(), m.IsAnimated(), test.image, test.animated)
+		}
+	}
+}
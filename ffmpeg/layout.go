@@ -0,0 +1,112 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Names of the layouts accepted by ParseLayout.
+const (
+	LayoutStrip  = "strip"
+	LayoutSquare = "square"
+)
+
+// Layout describes how the frames in a sprite are arranged.
+// The zero value arranges the frames in a single horizontal strip.
+type Layout struct {
+	// Columns is the number of frames in each row.
+	Columns int
+	// Rows is the number of rows. When Columns is also given, the sprite holds
+	// at most Columns*Rows frames and the remaining frames are left out.
+	Rows int
+	// Square arranges the frames in a grid which is as close to square as
+	// possible. Only used when Columns and Rows are both 0.
+	Square bool
+}
+
+// ParseLayout converts a string into a Layout.
+// The string may be "strip", "square", or a grid in the format "COLSxROWS".
+// Either side of the grid may be left out, eg "5x" or "x4", in which case it's
+// computed from the number of frames.
+func ParseLayout(s string) (Layout, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "", LayoutStrip:
+		return Layout{}, nil
+	case LayoutSquare:
+		return Layout{Square: true}, nil
+	}
+
+	parts := strings.SplitN(s, "x", 2)
+	if len(parts) != 2 || (parts[0] == "" && parts[1] == "") {
+		return Layout{}, fmt.Errorf("Invalid sprite layout %q.", s)
+	}
+
+	l := Layout{}
+	var err error
+	if parts[0] != "" {
+		if l.Columns, err = strconv.Atoi(parts[0]); err != nil || l.Columns < 1 {
+			return Layout{}, fmt.Errorf("Invalid number of columns in sprite layout %q.", s)
+		}
+	}
+	if parts[1] != "" {
+		if l.Rows, err = strconv.Atoi(parts[1]); err != nil || l.Rows < 1 {
+			return Layout{}, fmt.Errorf("Invalid number of rows in sprite layout %q.", s)
+		}
+	}
+
+	return l, nil
+}
+
+// Grid returns the number of columns and rows used to arrange 'n' frames.
+// Frames beyond the Limit of the layout are not counted.
+func (l Layout) Grid(n int) (int, int) {
+	n = l.Limit(n)
+	if n < 1 {
+		return 0, 0
+	}
+
+	cols := n
+	switch {
+	case l.Columns > 0:
+		cols = l.Columns
+	case l.Rows > 0:
+		cols = int(math.Ceil(float64(n) / float64(l.Rows)))
+	case l.Square:
+		cols = int(math.Ceil(math.Sqrt(float64(n))))
+	}
+	if cols > n {
+		cols = n
+	}
+	rows := int(math.Ceil(float64(n) / float64(cols)))
+
+	return cols, rows
+}
+
+// Limit returns how many of 'n' frames fit in the layout. Only a grid with both
+// Columns and Rows has a limit, of Columns*Rows frames.
+func (l Layout) Limit(n int) int {
+	if l.Columns > 0 && l.Rows > 0 && n > l.Columns*l.Rows {
+		return l.Columns * l.Rows
+	}
+
+	return n
+}
+
+// String implements fmt.Stringer.
+func (l Layout) String() string {
+	switch {
+	case l.Columns > 0 && l.Rows > 0:
+		return fmt.Sprintf("%dx%d", l.Columns, l.Rows)
+	case l.Columns > 0:
+		return fmt.Sprintf("%dx", l.Columns)
+	case l.Rows > 0:
+		return fmt.Sprintf("x%d", l.Rows)
+	case l.Square:
+		return LayoutSquare
+	}
+
+	return LayoutStrip
+}
@@ -0,0 +1,64 @@
+package ffmpeg
+
+import (
+	"testing"
+)
+
+func TestParseLayout(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Layout
+	}{
+		{"", Layout{}},
+		{"strip", Layout{}},
+		{"Square", Layout{Square: true}},
+		{"5x", Layout{Columns: 5}},
+		{"x4", Layout{Rows: 4}},
+		{"5x4", Layout{Columns: 5, Rows: 4}},
+	}
+
+	for _, test := range tests {
+		l, err := ParseLayout(test.s)
+		if err != nil {
+			t.Errorf("ParseLayout(%q) error: %s", test.s, err)
+			continue
+		}
+		if l != test.want {
+			t.Errorf("ParseLayout(%q) = %+v, want %+v", test.s, l, test.want)
+		}
+	}
+
+	for _, s := range []string{"x", "round", "0x4", "5x-1", "axb"} {
+		if _, err := ParseLayout(s); err == nil {
+			t.Errorf("ParseLayout(%q) did not return an error", s)
+		}
+	}
+}
+
+func TestLayoutGrid(t *testing.T) {
+	tests := []struct {
+		layout     Layout
+		n          int
+		cols, rows int
+		limit      int
+	}{
+		{Layout{}, 30, 30, 1, 30},
+		{Layout{Square: true}, 30, 6, 5, 30},
+		{Layout{Columns: 5}, 30, 5, 6, 30},
+		{Layout{Rows: 4}, 30, 8, 4, 30},
+		{Layout{Columns: 5, Rows: 4}, 30, 5, 4, 20},
+		{Layout{Columns: 5, Rows: 4}, 12, 5, 3, 12},
+		{Layout{Columns: 10}, 3, 3, 1, 3},
+		{Layout{}, 0, 0, 0, 0},
+	}
+
+	for _, test := range tests {
+		cols, rows := test.layout.Grid(test.n)
+		if cols != test.cols || rows != test.rows {
+			t.Errorf("%v.Grid(%d) = %dx%d, want %dx%d", test.layout, test.n, cols, rows, test.cols, test.rows)
+		}
+		if limit := test.layout.Limit(test.n); limit != test.limit {
+			t.Errorf("%v.Limit(%d) = %d, want %d", test.layout, test.n, limit, test.limit)
+		}
+	}
+}
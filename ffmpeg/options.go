@@ -0,0 +1,128 @@
+package ffmpeg
+
+import (
+	"time"
+)
+
+// Options determines how thumbnails are created.
+// Every VideoThumbnailer backend is created with a set of options, which are
+// used for each of its thumbnails.
+type Options struct {
+	// Skip is the position in the video of simple thumbnails, and where
+	// sprites, animations and previews begin.
+	Skip Timestamp
+	// Height is the height of thumbnails and sprite frames. 0 keeps the aspect
+	// ratio of the video.
+	Height int
+	// Fit determines how frames are sized when both the width and Height are
+	// given. One of FitContain, FitCover or FitStretch.
+	Fit string
+	// PadColor is the color of the padding added by FitContain.
+	PadColor string
+	// Layout determines how frames are arranged in sprites.
+	Layout Layout
+	// Padding is the number of pixels between the frames in sprites.
+	Padding int
+	// Background is the color used for the padding in sprites.
+	Background string
+	// Format is the image format thumbnails are written in.
+	Format Format
+	// Quality is the image quality of thumbnails, from 1 to 100.
+	Quality int
+	// Timeout limits how long each operation may run. 0 means no limit.
+	Timeout time.Duration
+	// Selection determines how frames are chosen for sprites. Either
+	// SelectInterval or SelectScene.
+	Selection string
+	// SceneThreshold is the minimum scene change score, from 0 to 1, of the
+	// frames chosen when Selection is SelectScene. 0 uses DefaultSceneThreshold.
+	SceneThreshold float64
+	// Candidates is the number of frames considered by CreateBestThumbnail.
+	// 0 uses DefaultCandidates.
+	Candidates int
+	// SearchWindow is the number of seconds on either side of Skip
+	// searched by CreateBestThumbnail. 0 searches the whole video.
+	SearchWindow int
+	// Segments is the number of excerpts used by CreateAnimated and
+	// CreatePreview. 0 uses DefaultSegments.
+	Segments int
+	// SegmentLength is the length in seconds of each excerpt used by
+	// CreateAnimated and CreatePreview. 0 uses DefaultSegmentLength.
+	SegmentLength float64
+	// FPS is the frame rate of animations. 0 uses DefaultFPS.
+	FPS int
+	// Palette enables palette generation for GIF animations.
+	Palette bool
+	// CRF is the x264 constant rate factor of preview clips, from 1 (best)
+	// to 51 (worst). 0 uses DefaultCRF.
+	CRF int
+	// Logf is called with a description of the corrections made to frames,
+	// such as rotation. Nothing is reported when it's nil.
+	Logf func(format string, a ...interface{})
+	// Seek is the strategy used to extract the frames of sprites. Either
+	// SeekFast or SeekAccurate. An empty string uses SeekFast.
+	Seek string
+	// Workers is the number of frames extracted at the same time. 0 uses
+	// DefaultWorkers.
+	Workers int
+	// WaveColor is the color of the wave drawn by CreateWaveform.
+	WaveColor string
+	// WaveBackground is the color behind the wave drawn by CreateWaveform.
+	WaveBackground string
+	// CoverArt makes CreateWaveform write the picture embedded in the file,
+	// such as the cover of an album, instead of drawing the waveform.
+	CoverArt bool
+	// Label is the text drawn on simple thumbnails and each frame of sprites.
+	// "{time}" is replaced by the position of the frame in the video. Nothing
+	// is drawn when it's empty.
+	Label string
+	// LabelFont is the font of the label, either a font file or a fontconfig
+	// font name. The ffmpeg default font is used when it's empty.
+	LabelFont string
+	// LabelSize is the font size of the label in pixels. 0 uses DefaultLabelSize.
+	LabelSize int
+	// LabelColor is the color of the label text.
+	LabelColor string
+	// LabelBox is the color of the box drawn behind the label, with an optional
+	// opacity, eg "black@0.5". No box is drawn when it's empty.
+	LabelBox string
+	// LabelPosition is where the label is drawn. PositionBottomLeft is used
+	// when it's empty.
+	LabelPosition Position
+	// Logo is an image file drawn over simple thumbnails at its own size.
+	// No logo is drawn when it's empty.
+	Logo string
+	// LogoPosition is where the logo is drawn. PositionBottomRight is used
+	// when it's empty.
+	LogoPosition Position
+	// LogoMargin is the number of pixels between the logo and the edges of
+	// the thumbnail.
+	LogoMargin int
+	// LogoOpacity is the opacity of the logo, from 0 to 1. 0 uses
+	// DefaultLogoOpacity.
+	LogoOpacity float64
+	// SheetColumns is the number of frames in each row of contact sheets.
+	// 0 uses DefaultSheetColumns.
+	SheetColumns int
+	// SheetMargin is the number of pixels around each frame and the header of
+	// contact sheets.
+	SheetMargin int
+	// SheetTitle is the file name shown in the header of contact sheets. The
+	// name of the video file is used when it's empty.
+	SheetTitle string
+}
+
+// DefaultOptions returns the options used by New.
+func DefaultOptions() Options {
+	return Options{
+		Background:     "black",
+		Fit:            FitContain,
+		PadColor:       "black",
+		Format:         FormatJPEG,
+		WaveColor:      "white",
+		WaveBackground: "black",
+		LabelColor:     "white",
+		LogoMargin:     DefaultLogoMargin,
+		SheetMargin:    DefaultSheetMargin,
+	}
+}
@@ -0,0 +1,84 @@
+package ffmpeg
+
+import (
+	"context"
+	"strings"
+)
+
+// orientFilter returns the video filter which turns frames upright and makes
+// their pixels square, so frames look the way a player shows them. An empty
+// string is returned when the video needs neither. Images are turned upright
+// using their EXIF orientation.
+// The filter expects ffmpeg to be run with -noautorotate, otherwise frames
+// would be rotated twice.
+func (f *FFmpeg) orientFilter(ctx context.Context) (string, error) {
+	m, err := f.Probe(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	filters := []string{}
+	if m.IsImage() {
+		if filter := f.imageOrientFilter(); filter != "" {
+			filters = append(filters, filter)
+		}
+	}
+	if len(filters) == 0 {
+		switch m.Rotation {
+		case 90:
+			filters = append(filters, "transpose=clock")
+		case 180:
+			filters = append(filters, "hflip,vflip")
+		case 270:
+			filters = append(filters, "transpose=cclock")
+		}
+		if len(filters) > 0 {
+			f.logf("Rotating frames of video %q by %d degrees.", f.Video, m.Rotation)
+		}
+	}
+
+	// The transpose filter also swaps the sample aspect ratio, so the
+	// width is always the side which needs stretching.
+	sar := parseRational(strings.Replace(m.SampleAspectRatio, ":", "/", 1))
+	if sar > 0 && sar != 1 {
+		filters = append(filters, "scale='trunc(iw*sar/2)*2':ih,setsar=1")
+		f.logf("Scaling frames of video %q from sample aspect ratio %s to square pixels.", f.Video, m.SampleAspectRatio)
+	}
+
+	return strings.Join(filters, ","), nil
+}
+
+// frameFilter returns the video filter which turns frames upright with
+// orientFilter, and then sizes them with sizeFilter.
+func (f *FFmpeg) frameFilter(ctx context.Context, width int) (string, error) {
+	size, err := f.sizeFilter(width)
+	if err != nil {
+		return "", err
+	}
+	orient, err := f.orientFilter(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return joinFilters(orient, size), nil
+}
+
+// joinFilters joins the given video filters into a single filter chain,
+// leaving out the empty ones.
+func joinFilters(filters ...string) string {
+	chain := []string{}
+	for _, filter := range filters {
+		if filter != "" {
+			chain = append(chain, filter)
+		}
+	}
+
+	return strings.Join(chain, ",")
+}
+
+// logf reports the given message with FFmpeg.Logf when it's set.
+func (f *FFmpeg) logf(format string, a ...interface{}) {
+	if f.Logf != nil {
+		f.Logf(format, a...)
+	}
+}
@@ -0,0 +1,40 @@
+package ffmpeg
+
+import (
+	"context"
+	"testing"
+)
+
+func TestOrientFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		rotation int
+		sar      string
+		want     string
+	}{
+		{"upright", 0, "1:1", ""},
+		{"no aspect ratio", 0, "", ""},
+		{"clockwise", 90, "1:1", "transpose=clock"},
+		{"upside down", 180, "1:1", "hflip,vflip"},
+		{"counterclockwise", 270, "1:1", "transpose=cclock"},
+		{"odd angle", 45, "1:1", ""},
+		{"anamorphic", 0, "4:3", "scale='trunc(iw*sar/2)*2':ih,setsar=1"},
+		{"rotated anamorphic", 90, "32:27", "transpose=clock,scale='trunc(iw*sar/2)*2':ih,setsar=1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := New("video.mp4")
+			f.metadata = &Metadata{
+				Format:            "mov,mp4,m4a,3gp,3g2,mj2",
+				Duration:          60,
+				Rotation:          test.rotation,
+				SampleAspectRatio: test.sar,
+			}
+			got, err := f.orientFilter(context.Background())
+			if err != nil || got != test.want {
+				t.Errorf("orientFilter() = %q, %v, want %q", got, err, test.want)
+			}
+		})
+	}
+}
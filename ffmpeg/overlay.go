@@ -0,0 +1,333 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Default values for the label and logo options.
+const (
+	DefaultLabelSize   = 16
+	DefaultLogoMargin  = 10
+	DefaultLogoOpacity = 1.0
+)
+
+// MaxLabelSize is the largest font size allowed for labels.
+const MaxLabelSize = 200
+
+// CheckLabelSize returns an error when 'size' is negative or larger than
+// MaxLabelSize. A size of 0 selects DefaultLabelSize.
+func CheckLabelSize(size int) error {
+	if size < 0 || size > MaxLabelSize {
+		return fmt.Errorf("Invalid label size %d. Use 0 to %d.", size, MaxLabelSize)
+	}
+	return nil
+}
+
+// MaxLogoMargin is the largest number of pixels allowed between the logo and
+// the edges of the frame.
+const MaxLogoMargin = 100
+
+// CheckLogoMargin returns an error when 'margin' is negative or larger than
+// MaxLogoMargin.
+func CheckLogoMargin(margin int) error {
+	if margin < 0 || margin > MaxLogoMargin {
+		return fmt.Errorf("Invalid logo margin %d. Use 0 to %d pixels.", margin, MaxLogoMargin)
+	}
+	return nil
+}
+
+// labelMargin is the number of pixels between a label and the edges of the frame.
+const labelMargin = 4
+
+// Position is where a label or logo is drawn on a frame.
+type Position string
+
+// Positions of labels and logos.
+const (
+	PositionTopLeft     Position = "top-left"
+	PositionTop         Position = "top"
+	PositionTopRight    Position = "top-right"
+	PositionCenter      Position = "center"
+	PositionBottomLeft  Position = "bottom-left"
+	PositionBottom      Position = "bottom"
+	PositionBottomRight Position = "bottom-right"
+)
+
+// positions lists every valid position.
+var positions = []Position{
+	PositionTopLeft,
+	PositionTop,
+	PositionTopRight,
+	PositionCenter,
+	PositionBottomLeft,
+	PositionBottom,
+	PositionBottomRight,
+}
+
+// ParsePosition converts the name of a position into a Position.
+func ParsePosition(s string) (Position, error) {
+	p := Position(strings.ToLower(strings.TrimSpace(s)))
+	for _, position := range positions {
+		if p == position {
+			return p, nil
+		}
+	}
+
+	return "", fmt.Errorf("Invalid position %q.", s)
+}
+
+// coords returns the ffmpeg expressions for the x and y coordinates of an item
+// 'w' by 'h' pixels drawn at the position, inside a frame 'frameW' by 'frameH'
+// pixels, 'margin' pixels away from the edges.
+func (p Position) coords(margin int, frameW, frameH, w, h string) (string, string) {
+	left, top := strconv.Itoa(margin), strconv.Itoa(margin)
+	right := fmt.Sprintf("%s-%s-%d", frameW, w, margin)
+	bottom := fmt.Sprintf("%s-%s-%d", frameH, h, margin)
+	centerX := fmt.Sprintf("(%s-%s)/2", frameW, w)
+	centerY := fmt.Sprintf("(%s-%s)/2", frameH, h)
+
+	switch p {
+	case PositionTopLeft:
+		return left, top
+	case PositionTop:
+		return centerX, top
+	case PositionTopRight:
+		return right, top
+	case PositionCenter:
+		return centerX, centerY
+	case PositionBottomLeft:
+		return left, bottom
+	case PositionBottom:
+		return centerX, bottom
+	}
+
+	return right, bottom
+}
+
+// ParseAlphaColor converts a color accepted by ParseColor, optionally followed
+// by "@" and an opacity from 0 to 1, eg "black@0.5", into a color.RGBA. The
+// opacity is stored in the alpha channel of the color.
+func ParseAlphaColor(s string) (color.RGBA, error) {
+	parts := strings.SplitN(s, "@", 2)
+	c, err := ParseColor(parts[0])
+	if err != nil {
+		return color.RGBA{}, err
+	}
+	if len(parts) == 2 {
+		alpha, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || alpha < 0 || alpha > 1 {
+			return color.RGBA{}, fmt.Errorf("Invalid color opacity %q.", s)
+		}
+		c.A = uint8(alpha * 0xff)
+	}
+
+	return c, nil
+}
+
+// labeler returns a function which returns the filter drawing the label 'text'
+// on the frame at 't' seconds in the video, using the label options. A nil
+// function is returned when 'text' is empty.
+func (f *FFmpeg) labeler(text string) (func(t float64) string, error) {
+	if text == "" {
+		return nil, nil
+	}
+	// The options are checked once, so the function never fails.
+	if _, err := f.drawtext("", false); err != nil {
+		return nil, err
+	}
+
+	return func(t float64) string {
+		filter, _ := f.drawtext(strings.Replace(text, "{time}", FormatTime(t), -1), false)
+		return filter
+	}, nil
+}
+
+// ptsLabelFilter returns the filter drawing FFmpeg.Label on frames using the
+// time read from the frame timestamps as the video is decoded. It's used when
+// many frames are extracted in a single pass. Percent signs in the label are
+// escaped so only the time is expanded. An empty string is returned when
+// FFmpeg.Label is empty.
+func (f *FFmpeg) ptsLabelFilter() (string, error) {
+	if f.Label == "" {
+		return "", nil
+	}
+	text := strings.Replace(f.Label, "%", "%%", -1)
+	return f.drawtext(strings.Replace(text, "{time}", "%{pts:hms}", -1), true)
+}
+
+// drawtext returns the drawtext filter which draws 'text' using the label
+// options. Expansions such as "%{pts}" are only replaced when 'expand' is true.
+func (f *FFmpeg) drawtext(text string, expand bool) (string, error) {
+	position := PositionBottomLeft
+	if f.LabelPosition != "" {
+		p, err := ParsePosition(string(f.LabelPosition))
+		if err != nil {
+			return "", err
+		}
+		position = p
+	}
+	font, err := f.fontOptions()
+	if err != nil {
+		return "", err
+	}
+
+	expansion := "none"
+	if expand {
+		expansion = "normal"
+	}
+	x, y := position.coords(labelMargin, "w", "h", "tw", "th")
+	opts := []string{
+		"text=" + filterQuote(text),
+		"expansion=" + expansion,
+	}
+	opts = append(opts, font...)
+	opts = append(opts, "x="+x, "y="+y)
+	if f.LabelBox != "" {
+		box, err := ParseAlphaColor(f.LabelBox)
+		if err != nil {
+			return "", err
+		}
+		opts = append(opts, "box=1", "boxcolor="+alphaHexColor(box), "boxborderw="+strconv.Itoa(f.labelSize()/4+1))
+	}
+
+	return "drawtext=" + strings.Join(opts, ":"), nil
+}
+
+// fontOptions returns the drawtext options which set the font, size and color
+// of text using the label options.
+func (f *FFmpeg) fontOptions() ([]string, error) {
+	labelColor := f.LabelColor
+	if labelColor == "" {
+		labelColor = "white"
+	}
+	fg, err := ParseAlphaColor(labelColor)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []string{
+		"fontsize=" + strconv.Itoa(f.labelSize()),
+		"fontcolor=" + alphaHexColor(fg),
+	}
+	if f.LabelFont != "" {
+		// Font files are told apart from fontconfig names by their extension.
+		if filepath.Ext(f.LabelFont) != "" {
+			opts = append(opts, "fontfile="+filterQuote(f.LabelFont))
+		} else {
+			opts = append(opts, "font="+filterQuote(f.LabelFont))
+		}
+	}
+
+	return opts, nil
+}
+
+// labelSize returns Options.LabelSize, or DefaultLabelSize when it's not set.
+func (o Options) labelSize() int {
+	if o.LabelSize < 1 {
+		return DefaultLabelSize
+	}
+	return o.LabelSize
+}
+
+// extractThumbnail writes the frame at the given time to 'outFile' like
+// extractFrame, and draws the label and logo options over it.
+func (f *FFmpeg) extractThumbnail(ctx context.Context, t float64, filter, outFile string, enc encoder) error {
+	label, err := f.labeler(f.Label)
+	if err != nil {
+		return err
+	}
+	if label != nil {
+		filter = joinFilters(filter, label(t))
+	}
+	if f.Logo == "" {
+		return f.extractFrame(ctx, t, filter, outFile, enc)
+	}
+
+	graph, err := f.logoGraph(filter)
+	if err != nil {
+		return err
+	}
+	os.Remove(outFile)
+
+	args := []string{
+		"-noautorotate",
+		"-ss",
+		formatSeconds(t),
+		"-i",
+		f.Video,
+		"-i",
+		f.Logo,
+		"-filter_complex",
+		graph,
+		"-map",
+		"[out]",
+		"-frames:v",
+		"1",
+	}
+	args = append(args, enc.args()...)
+	args = append(args, outFile)
+
+	err = run(ctx, CmdFFmpeg, args...)
+	if err != nil {
+		os.Remove(outFile)
+		return err
+	}
+
+	return nil
+}
+
+// logoGraph returns the ffmpeg filter graph which applies the video filter
+// 'filter' to the first input, and draws the logo from the second input over
+// it using the logo options. The result is labeled "[out]".
+func (f *FFmpeg) logoGraph(filter string) (string, error) {
+	if !fileExists(f.Logo) {
+		return "", fmt.Errorf("Logo %q does not exist.", f.Logo)
+	}
+	position := PositionBottomRight
+	if f.LogoPosition != "" {
+		p, err := ParsePosition(string(f.LogoPosition))
+		if err != nil {
+			return "", err
+		}
+		position = p
+	}
+	opacity := f.LogoOpacity
+	if opacity <= 0 || opacity > 1 {
+		opacity = DefaultLogoOpacity
+	}
+	margin := f.LogoMargin
+	if margin < 0 {
+		margin = DefaultLogoMargin
+	}
+
+	if filter == "" {
+		filter = "null"
+	}
+	logo := "format=rgba"
+	if opacity < 1 {
+		logo += fmt.Sprintf(",colorchannelmixer=aa=%.2f", opacity)
+	}
+	x, y := position.coords(margin, "W", "H", "w", "h")
+
+	return fmt.Sprintf("[0:v]%s[base];[1:v]%s[logo];[base][logo]overlay=x=%s:y=%s[out]", filter, logo, x, y), nil
+}
+
+// filterQuote quotes a filter option value so that ffmpeg reads it literally.
+// Single quotes can't be escaped inside the value, so they're replaced by
+// apostrophes.
+func filterQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `:`, `\:`, `'`, "’").Replace(s)
+	return "'" + s + "'"
+}
+
+// alphaHexColor returns the color in the hex format used by ffmpeg filters,
+// along with its opacity.
+func alphaHexColor(c color.RGBA) string {
+	return fmt.Sprintf("%s@%.2f", hexColor(c), float64(c.A)/0xff)
+}
@@ -0,0 +1,132 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParsePosition(t *testing.T) {
+	if p, err := ParsePosition(" Top-Right "); err != nil || p != PositionTopRight {
+		t.Errorf("ParsePosition(top-right) = %q, %v, want %q", p, err, PositionTopRight)
+	}
+	if _, err := ParsePosition("middle"); err == nil {
+		t.Error("ParsePosition(middle) error = nil, want an error")
+	}
+}
+
+func TestParseAlphaColor(t *testing.T) {
+	c, err := ParseAlphaColor("black@0.5")
+	if err != nil || c.A != 0x7f {
+		t.Errorf("ParseAlphaColor(black@0.5) = %v, %v, want alpha 0x7f", c, err)
+	}
+	for _, s := range []string{"black@2", "black@half", "plaid"} {
+		if _, err := ParseAlphaColor(s); err == nil {
+			t.Errorf("ParseAlphaColor(%q) error = nil, want an error", s)
+		}
+	}
+}
+
+func TestLabeler(t *testing.T) {
+	f := New("video.mp4")
+	f.Label = "{time}"
+	f.LabelBox = "black@0.5"
+	f.LabelPosition = PositionTopRight
+
+	label, err := f.labeler(f.Label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `drawtext=text='00\:01\:30.500':expansion=none:fontsize=16:fontcolor=0xffffff@1.00:` +
+		`x=w-tw-4:y=4:box=1:boxcolor=0x000000@0.50:boxborderw=5`
+	if got := label(90.5); got != want {
+		t.Errorf("label(90.5) = %q, want %q", got, want)
+	}
+
+	f.Label = ""
+	if label, err := f.labeler(f.Label); label != nil || err != nil {
+		t.Errorf("labeler() without a label returned a function or error %v, want neither", err)
+	}
+}
+
+func TestPTSLabelFilter(t *testing.T) {
+	f := New("video.mp4")
+	f.Label = "It's {time}"
+	f.LabelFont = "DejaVu Sans"
+
+	want := `drawtext=text='It’s %{pts\:hms}':expansion=normal:fontsize=16:fontcolor=0xffffff@1.00:` +
+		`font='DejaVu Sans':x=4:y=h-th-4`
+	if got, err := f.ptsLabelFilter(); err != nil || got != want {
+		t.Errorf("ptsLabelFilter() = %q, %v, want %q", got, err, want)
+	}
+}
+
+func TestPTSLabelFilterEscapesPercent(t *testing.T) {
+	tests := []struct {
+		label string
+		want  string
+	}{
+		{"100% {time}", `text='100%% %{pts\:hms}'`},
+		{"%{pts} {time}", `text='%%{pts} %{pts\:hms}'`},
+		{"%{eif:1/0:d}", `text='%%{eif\:1/0\:d}'`},
+	}
+
+	for _, test := range tests {
+		f := New("video.mp4")
+		f.Label = test.label
+		got, err := f.ptsLabelFilter()
+		if err != nil || !strings.HasPrefix(got, "drawtext="+test.want+":") {
+			t.Errorf("ptsLabelFilter() with label %q = %q, %v, want %s", test.label, got, err, test.want)
+		}
+	}
+}
+
+func TestCheckLogoMargin(t *testing.T) {
+	for _, margin := range []int{0, DefaultLogoMargin, MaxLogoMargin} {
+		if err := CheckLogoMargin(margin); err != nil {
+			t.Errorf("CheckLogoMargin(%d) error: %s", margin, err)
+		}
+	}
+	for _, margin := range []int{-1, MaxLogoMargin + 1} {
+		if err := CheckLogoMargin(margin); err == nil {
+			t.Errorf("CheckLogoMargin(%d) did not return an error", margin)
+		}
+	}
+}
+
+func TestLogoGraph(t *testing.T) {
+	logo, err := ioutil.TempFile("", "logo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logo.Close()
+	defer os.Remove(logo.Name())
+
+	f := New("video.mp4")
+	f.Logo = logo.Name()
+	f.LogoOpacity = 0.5
+	want := "[0:v]scale=320:-2[base];[1:v]format=rgba,colorchannelmixer=aa=0.50[logo];" +
+		"[base][logo]overlay=x=W-w-10:y=H-h-10[out]"
+	if got, err := f.logoGraph("scale=320:-2"); err != nil || got != want {
+		t.Errorf("logoGraph() = %q, %v, want %q", got, err, want)
+	}
+
+	f.Logo = logo.Name() + ".missing"
+	if _, err := f.logoGraph(""); err == nil {
+		t.Error("logoGraph() with a missing logo error = nil, want an error")
+	}
+}
+
+func TestCheckLabelSize(t *testing.T) {
+	for _, size := range []int{0, DefaultLabelSize, MaxLabelSize} {
+		if err := CheckLabelSize(size); err != nil {
+			t.Errorf("CheckLabelSize(%d) error: %s", size, err)
+		}
+	}
+	for _, size := range []int{-1, MaxLabelSize + 1, 100000} {
+		if err := CheckLabelSize(size); err == nil {
+			t.Errorf("CheckLabelSize(%d) did not return an error", size)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// DefaultCRF is the x264 constant rate factor used by CreatePreview when FFmpeg.CRF is 0.
+const DefaultCRF = 28
+
+// MaxCRF is the largest, and worst, x264 constant rate factor.
+const MaxCRF = 51
+
+// previewEncoder is the ffmpeg encoder used to write preview clips.
+const previewEncoder = "libx264"
+
+// CreatePreview creates a short silent H.264 MP4 clip from the video.
+// The clip is made from FFmpeg.Segments evenly spaced excerpts of the video,
+// each FFmpeg.SegmentLength seconds long, and encoded with a constant rate
+// factor of FFmpeg.CRF. When 0 is given for the 'width' argument, the clip
+// will have the same width of the video.
+func (f *FFmpeg) CreatePreview(ctx context.Context, width int, outFile string) error {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+	os.Remove(outFile)
+
+	names, err := availableEncoders(ctx)
+	if err != nil {
+		return err
+	}
+	if !names[previewEncoder] {
+		return fmt.Errorf("%w Cannot write preview clips without the %s encoder.", ErrUnsupportedFormat, previewEncoder)
+	}
+	crf := f.CRF
+	if crf < 1 {
+		crf = DefaultCRF
+	}
+
+	starts, length, err := f.segmentTimes(ctx)
+	if err != nil {
+		return err
+	}
+
+	orient, err := f.orientFilter(ctx)
+	if err != nil {
+		return err
+	}
+	// H.264 requires the width and height to be even.
+	filters := "scale='trunc(iw/2)*2':-2"
+	if width != 0 {
+		filters = fmt.Sprintf("scale='trunc(min(%d\\,iw)/2)*2':-2", width)
+	}
+	filters = joinFilters(orient, filters)
+
+	args := segmentInputs(f.Video, starts, length)
+	args = append(args,
+		"-filter_complex",
+		segmentGraph(len(starts), filters),
+		"-map",
+		"[out]",
+		"-an",
+		"-c:v",
+		previewEncoder,
+		"-preset",
+		"veryfast",
+		"-crf",
+		strconv.Itoa(crf),
+		"-pix_fmt",
+		"yuv420p",
+		"-movflags",
+		"+faststart",
+		"-f",
+		"mp4",
+		outFile)
+
+	err = run(ctx, CmdFFmpeg, args...)
+	if err != nil {
+		os.Remove(outFile)
+		return err
+	}
+
+	return nil
+}
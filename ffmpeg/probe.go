@@ -0,0 +1,273 @@
+package ffmpeg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrNoVideoStream is returned by Probe when the file does not contain a video stream.
+var ErrNoVideoStream = errors.New("The file does not contain a video stream.")
+
+// Metadata describes a video file as reported by ffprobe.
+// The top level dimensions, rotation, frame rate and video codec are taken
+// from the first video stream in the file.
+type Metadata struct {
+	// Duration is the length of the video in seconds. It's 0 for still images.
+	Duration float64
+	// Width is the width of the video frames in pixels, before rotation.
+	Width int
+	// Height is the height of the video frames in pixels, before rotation.
+	Height int
+	// SampleAspectRatio is the pixel aspect ratio, eg "1:1".
+	SampleAspectRatio string
+	// DisplayAspectRatio is the aspect ratio the video is displayed with, eg "16:9".
+	DisplayAspectRatio string
+	// Rotation is the number of degrees the video is rotated clockwise when displayed.
+	Rotation int
+	// FrameRate is the number of frames per second.
+	FrameRate float64
+	// VideoCodec is the name of the codec used by the video stream.
+	VideoCodec string
+	// AudioCodec is the name of the codec used by the first audio stream, if any.
+	AudioCodec string
+	// Format is the name of the container format, eg "mov,mp4,m4a,3gp,3g2,mj2".
+	Format string
+	// FormatLongName is the descriptive name of the container format.
+	FormatLongName string
+	// MajorBrand is the brand of mp4 and similar files, eg "isom" or "heic".
+	MajorBrand string
+	// BitRate is the overall bit rate of the file in bits per second.
+	BitRate int64
+	// Size is the size of the file in bytes.
+	Size int64
+	// Streams lists every stream in the file.
+	Streams []Stream
+	// Chapters lists the chapters in the file.
+	Chapters []Chapter
+}
+
+// Stream describes a single stream in a file.
+type Stream struct {
+	Index              int
+	Type               string
+	Codec              string
+	CodecLongName      string
+	Width              int
+	Height             int
+	SampleAspectRatio  string
+	DisplayAspectRatio string
+	Rotation           int
+	FrameRate          float64
+	Duration           float64
+	BitRate            int64
+	SampleRate         int
+	Channels           int
+	Language           string
+	// AttachedPic is true when the stream is an embedded picture such as cover art.
+	AttachedPic bool
+}
+
+// Chapter describes a single chapter in a file.
+type Chapter struct {
+	ID    int64
+	Start float64
+	End   float64
+	Title string
+}
+
+// probeOutput is the JSON document written by ffprobe.
+type probeOutput struct {
+	Streams []struct {
+		Index              int               `json:"index"`
+		CodecName          string            `json:"codec_name"`
+		CodecLongName      string            `json:"codec_long_name"`
+		CodecType          string            `json:"codec_type"`
+		Width              int               `json:"width"`
+		Height             int               `json:"height"`
+		SampleAspectRatio  string            `json:"sample_aspect_ratio"`
+		DisplayAspectRatio string            `json:"display_aspect_ratio"`
+		RFrameRate         string            `json:"r_frame_rate"`
+		AvgFrameRate       string            `json:"avg_frame_rate"`
+		Duration           string            `json:"duration"`
+		BitRate            string            `json:"bit_rate"`
+		SampleRate         string            `json:"sample_rate"`
+		Channels           int               `json:"channels"`
+		Disposition        map[string]int    `json:"disposition"`
+		Tags               map[string]string `json:"tags"`
+		SideDataList       []struct {
+			SideDataType string  `json:"side_data_type"`
+			Rotation     float64 `json:"rotation"`
+		} `json:"side_data_list"`
+	} `json:"streams"`
+	Chapters []struct {
+		ID        int64             `json:"id"`
+		StartTime string            `json:"start_time"`
+		EndTime   string            `json:"end_time"`
+		Tags      map[string]string `json:"tags"`
+	} `json:"chapters"`
+	Format struct {
+		FormatName     string            `json:"format_name"`
+		FormatLongName string            `json:"format_long_name"`
+		Duration       string            `json:"duration"`
+		Size           string            `json:"size"`
+		BitRate        string            `json:"bit_rate"`
+		Tags           map[string]string `json:"tags"`
+	} `json:"format"`
+}
+
+// Probe runs ffprobe on the given video or image file and returns its metadata.
+// ErrNoVideoStream is returned when the file does not contain a video stream,
+// and an error matching IsInvalidInput when ffprobe cannot read the file.
+// The ffprobe process is killed when ctx is done.
+func Probe(ctx context.Context, video string) (*Metadata, error) {
+	if CmdFFprobe == "" {
+		CmdFFprobe = "ffprobe"
+	}
+
+	output, err := output(
+		ctx,
+		CmdFFprobe,
+		"-v",
+		"error",
+		"-print_format",
+		"json",
+		"-show_format",
+		"-show_streams",
+		"-show_chapters",
+		video,
+	)
+	if err != nil {
+		if IsInvalidInput(err) {
+			return nil, fmt.Errorf("File %q is not a supported video or image: %w", video, err)
+		}
+		return nil, err
+	}
+
+	return parseProbeOutput(output)
+}
+
+// parseProbeOutput converts the JSON written by ffprobe into a Metadata.
+func parseProbeOutput(output []byte) (*Metadata, error) {
+	po := probeOutput{}
+	if err := json.Unmarshal(output, &po); err != nil {
+		return nil, err
+	}
+
+	m := &Metadata{
+		Format:         po.Format.FormatName,
+		FormatLongName: po.Format.FormatLongName,
+		Duration:       parseFloat(po.Format.Duration),
+		BitRate:        parseInt(po.Format.BitRate),
+		Size:           parseInt(po.Format.Size),
+		MajorBrand:     po.Format.Tags["major_brand"],
+	}
+
+	video := -1
+	for _, ps := range po.Streams {
+		s := Stream{
+			Index:              ps.Index,
+			Type:               ps.CodecType,
+			Codec:              ps.CodecName,
+			CodecLongName:      ps.CodecLongName,
+			Width:              ps.Width,
+			Height:             ps.Height,
+			SampleAspectRatio:  ps.SampleAspectRatio,
+			DisplayAspectRatio: ps.DisplayAspectRatio,
+			FrameRate:          parseRational(ps.AvgFrameRate),
+			Duration:           parseFloat(ps.Duration),
+			BitRate:            parseInt(ps.BitRate),
+			SampleRate:         int(parseInt(ps.SampleRate)),
+			Channels:           ps.Channels,
+			Language:           ps.Tags["language"],
+			AttachedPic:        ps.Disposition["attached_pic"] == 1,
+		}
+		if s.FrameRate == 0 {
+			s.FrameRate = parseRational(ps.RFrameRate)
+		}
+		if r, ok := ps.Tags["rotate"]; ok {
+			s.Rotation = int(parseInt(r))
+		}
+		for _, sd := range ps.SideDataList {
+			// The display matrix holds the counterclockwise rotation, which
+			// may not be a whole number of degrees, eg -89.99.
+			if sd.SideDataType == "Display Matrix" {
+				s.Rotation = -int(math.Round(sd.Rotation))
+			}
+		}
+		s.Rotation = ((s.Rotation % 360) + 360) % 360
+
+		if s.Type == "video" && !s.AttachedPic && video == -1 {
+			video = len(m.Streams)
+		}
+		if s.Type == "audio" && m.AudioCodec == "" {
+			m.AudioCodec = s.Codec
+		}
+		m.Streams = append(m.Streams, s)
+	}
+
+	for _, pc := range po.Chapters {
+		m.Chapters = append(m.Chapters, Chapter{
+			ID:    pc.ID,
+			Start: parseFloat(pc.StartTime),
+			End:   parseFloat(pc.EndTime),
+			Title: pc.Tags["title"],
+		})
+	}
+
+	if video == -1 {
+		return m, ErrNoVideoStream
+	}
+	vs := m.Streams[video]
+	m.Width = vs.Width
+	m.Height = vs.Height
+	m.SampleAspectRatio = vs.SampleAspectRatio
+	m.DisplayAspectRatio = vs.DisplayAspectRatio
+	m.Rotation = vs.Rotation
+	m.FrameRate = vs.FrameRate
+	m.VideoCodec = vs.Codec
+	if m.Duration == 0 {
+		m.Duration = vs.Duration
+	}
+	// Still images are reported as lasting a single frame.
+	if m.isStillImage() {
+		m.Duration = 0
+	}
+
+	return m, nil
+}
+
+// parseFloat converts a string to a float, returning 0 when the string is not a number.
+func parseFloat(s string) float64 {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0.0
+	}
+	return f
+}
+
+// parseInt converts a string to an integer, returning 0 when the string is not a number.
+func parseInt(s string) int64 {
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return i
+}
+
+// parseRational converts a rational number in the format "30000/1001" to a float.
+func parseRational(s string) float64 {
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 {
+		return parseFloat(s)
+	}
+	num, den := parseFloat(parts[0]), parseFloat(parts[1])
+	if den == 0 {
+		return 0.0
+	}
+	return num / den
+}
@@ -0,0 +1,41 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseProbeOutputRotation(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream string
+		want   int
+	}{
+		{"none", `"tags": {}`, 0},
+		{"rotate tag", `"tags": {"rotate": "90"}`, 90},
+		{"negative rotate tag", `"tags": {"rotate": "-90"}`, 270},
+		{"display matrix", `"side_data_list": [{"side_data_type": "Display Matrix", "displaymatrix": "\n00000000:            0       65536           0\n00000001:       -65536           0           0\n00000002:            0           0  1073741824\n", "rotation": -90}]`, 90},
+		{"display matrix with decimals", `"side_data_list": [{"side_data_type": "Display Matrix", "rotation": -90.00}]`, 90},
+		{"counterclockwise display matrix", `"side_data_list": [{"side_data_type": "Display Matrix", "rotation": 90}]`, 270},
+		{"upside down display matrix", `"side_data_list": [{"side_data_type": "Display Matrix", "rotation": 180}]`, 180},
+		{"negative upside down display matrix", `"side_data_list": [{"side_data_type": "Display Matrix", "rotation": -180}]`, 180},
+		{"inexact display matrix", `"side_data_list": [{"side_data_type": "Display Matrix", "rotation": -89.99}]`, 90},
+		{"other side data", `"side_data_list": [{"side_data_type": "Stereo 3D", "rotation": -90}]`, 0},
+		{"display matrix overrides tag", `"tags": {"rotate": "180"}, "side_data_list": [{"side_data_type": "Display Matrix", "rotation": -90}]`, 90},
+		{"full turn", `"tags": {"rotate": "360"}`, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := fmt.Sprintf(`{"format": {"format_name": "mov,mp4,m4a,3gp,3g2,mj2", "duration": "60.0"},
+				"streams": [{"codec_type": "video", "width": 1920, "height": 1080, %s}]}`, test.stream)
+			m, err := parseProbeOutput([]byte(output))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Rotation != test.want || m.Streams[0].Rotation != test.want {
+				t.Errorf("Rotation = %d, stream rotation = %d, want %d", m.Rotation, m.Streams[0].Rotation, test.want)
+			}
+		})
+	}
+}
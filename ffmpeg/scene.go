@@ -0,0 +1,188 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Frame selection modes for sprites.
+const (
+	// SelectInterval chooses frames evenly spaced across the video.
+	SelectInterval = "interval"
+	// SelectScene chooses the frames where the scene changes the most.
+	SelectScene = "scene"
+)
+
+// DefaultSceneThreshold is the scene change score used when FFmpeg.SceneThreshold is 0.
+const DefaultSceneThreshold = 0.3
+
+// sceneDetectWidth is the width frames are scaled down to before scoring
+// scene changes, which is much faster than scoring full size frames.
+const sceneDetectWidth = 160
+
+// ParseSelection validates a sprite frame selection mode, and returns it in
+// its normalized form. An empty string selects SelectInterval.
+func ParseSelection(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "", SelectInterval:
+		return SelectInterval, nil
+	case SelectScene:
+		return SelectScene, nil
+	}
+
+	return "", fmt.Errorf("Invalid frame selection %q.", s)
+}
+
+// sceneChange is a frame where the scene changes.
+type sceneChange struct {
+	// Time is the position of the frame in the video, in seconds.
+	Time float64
+	// Score is how much the frame differs from the previous one, from 0 to 1.
+	Score float64
+}
+
+// sceneTimes returns the times of the frames to use in a sprite when
+// selecting frames by scene change.
+// Up to one frame is chosen for every 'interval' seconds of video, preferring
+// the biggest scene changes. When the video has too few scene changes the
+// remaining frames are chosen evenly spaced between them.
+func (f *FFmpeg) sceneTimes(ctx context.Context, start float64, interval int) ([]float64, error) {
+	length, err := f.Length(ctx)
+	if err != nil {
+		return nil, err
+	}
+	count := int(length-start) / interval
+	if count < 1 {
+		count = 1
+	}
+
+	changes, err := f.sceneChanges(ctx, start)
+	if err != nil {
+		return nil, err
+	}
+
+	return chooseSceneTimes(changes, count, start, length, float64(interval)), nil
+}
+
+// sceneChanges returns the frames after 'start' seconds where the scene change
+// score is above the threshold.
+func (f *FFmpeg) sceneChanges(ctx context.Context, start float64) ([]sceneChange, error) {
+	threshold := f.SceneThreshold
+	if threshold == 0 {
+		threshold = DefaultSceneThreshold
+	}
+
+	filters := []string{
+		scaleFilter(sceneDetectWidth),
+		fmt.Sprintf("select='gt(scene\\,%f)'", threshold),
+		"metadata=print:file=-",
+	}
+	output, err := output(
+		ctx,
+		CmdFFmpeg,
+		"-ss",
+		formatSeconds(start),
+		"-i",
+		f.Video,
+		"-an",
+		"-sn",
+		"-vf",
+		strings.Join(filters, ","),
+		"-f",
+		"null",
+		"-",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := parseSceneChanges(output)
+	for i := range changes {
+		changes[i].Time += start
+	}
+
+	return changes, nil
+}
+
+// parseSceneChanges parses the output of the metadata filter.
+// The output has a line with the frame time followed by a line with the scene
+// score for each frame, eg:
+//
+//	frame:0    pts:12012   pts_time:12.012
+//	lavfi.scene_score=0.532142
+//
+// Frames with a missing or invalid time, eg "pts_time:N/A", are left out along
+// with their score.
+func parseSceneChanges(output []byte) []sceneChange {
+	changes := []sceneChange{}
+	valid := false
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "pts_time:"); i != -1 {
+			fields := strings.Fields(line[i+len("pts_time:"):])
+			valid = false
+			if len(fields) > 0 {
+				if t, err := strconv.ParseFloat(fields[0], 64); err == nil {
+					changes = append(changes, sceneChange{Time: t})
+					valid = true
+				}
+			}
+		} else if strings.HasPrefix(line, "lavfi.scene_score=") && valid {
+			changes[len(changes)-1].Score = parseFloat(strings.TrimPrefix(line, "lavfi.scene_score="))
+		}
+	}
+
+	return changes
+}
+
+// chooseSceneTimes picks up to 'count' times between 'start' and 'end' from
+// the given scene changes, preferring the highest scores. Changes closer than
+// half of 'interval' to an already chosen time are skipped. When there are not
+// enough changes the remaining times are filled in with evenly spaced times
+// which are not too close to the chosen changes. The times are returned in order.
+func chooseSceneTimes(changes []sceneChange, count int, start, end, interval float64) []float64 {
+	minGap := interval / 2
+	sorted := make([]sceneChange, len(changes))
+	copy(sorted, changes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Score > sorted[j].Score
+	})
+
+	times := []float64{}
+	add := func(t float64) bool {
+		for _, chosen := range times {
+			if math.Abs(chosen-t) < minGap {
+				return false
+			}
+		}
+		times = append(times, t)
+		return true
+	}
+
+	for _, change := range sorted {
+		if len(times) == count {
+			break
+		}
+		add(change.Time)
+	}
+	if len(times) < count {
+		step := (end - start) / float64(count)
+		for i := 0; i < count && len(times) < count; i++ {
+			add(start + float64(i)*step)
+		}
+	}
+	if len(times) == 0 {
+		times = append(times, start)
+	}
+	sort.Float64s(times)
+
+	return times
+}
@@ -0,0 +1,127 @@
+package ffmpeg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSceneChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []sceneChange
+	}{
+		{
+			name: "frames",
+			output: "frame:0    pts:12012   pts_time:12.012\n" +
+				"lavfi.scene_score=0.532142\n" +
+				"frame:1    pts:45045   pts_time:45.045\n" +
+				"lavfi.scene_score=0.250000\n",
+			want: []sceneChange{{12.012, 0.532142}, {45.045, 0.25}},
+		},
+		{
+			name: "missing score",
+			output: "frame:0    pts:12012   pts_time:12.012\n" +
+				"frame:1    pts:45045   pts_time:45.045\n" +
+				"lavfi.scene_score=0.250000\n",
+			want: []sceneChange{{12.012, 0}, {45.045, 0.25}},
+		},
+		{
+			name: "malformed times",
+			output: "frame:0    pts:12012   pts_time:12.012\n" +
+				"lavfi.scene_score=0.532142\n" +
+				"frame:1    pts:NOPTS   pts_time:N/A\n" +
+				"lavfi.scene_score=0.900000\n" +
+				"frame:2    pts:45045   pts_time:\n" +
+				"lavfi.scene_score=0.800000\n" +
+				"frame:3    pts:60060   pts_time:60.060\n" +
+				"lavfi.scene_score=0.400000\n",
+			want: []sceneChange{{12.012, 0.532142}, {60.06, 0.4}},
+		},
+		{
+			name:   "score before any frame",
+			output: "lavfi.scene_score=0.900000\nframe:0    pts:0   pts_time:0\n",
+			want:   []sceneChange{{0, 0}},
+		},
+		{
+			name:   "no frames",
+			output: "[Parsed_metadata_1 @ 0x55d] something else\n",
+			want:   []sceneChange{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := parseSceneChanges([]byte(test.output))
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parseSceneChanges() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestChooseSceneTimes(t *testing.T) {
+	tests := []struct {
+		name       string
+		changes    []sceneChange
+		count      int
+		start, end float64
+		interval   float64
+		want       []float64
+	}{
+		{
+			name:     "highest scores",
+			changes:  []sceneChange{{10, 0.3}, {20, 0.9}, {30, 0.5}, {40, 0.8}},
+			count:    2,
+			start:    0,
+			end:      50,
+			interval: 10,
+			want:     []float64{20, 40},
+		},
+		{
+			name:     "fewer changes than requested",
+			changes:  []sceneChange{{22, 0.9}},
+			count:    4,
+			start:    0,
+			end:      40,
+			interval: 10,
+			want:     []float64{0, 10, 22, 30},
+		},
+		{
+			name:     "no changes",
+			changes:  []sceneChange{},
+			count:    3,
+			start:    6,
+			end:      36,
+			interval: 10,
+			want:     []float64{6, 16, 26},
+		},
+		{
+			name:     "changes clustered near the start",
+			changes:  []sceneChange{{1, 0.9}, {1.5, 0.8}, {2, 0.7}, {2.5, 0.6}},
+			count:    4,
+			start:    0,
+			end:      40,
+			interval: 10,
+			want:     []float64{1, 10, 20, 30},
+		},
+		{
+			name:     "nothing fits",
+			changes:  []sceneChange{},
+			count:    0,
+			start:    5,
+			end:      10,
+			interval: 10,
+			want:     []float64{5},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := chooseSceneTimes(test.changes, test.count, test.start, test.end, test.interval)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("chooseSceneTimes() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package ffmpeg
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Weights given to each measurement when scoring a frame.
+const (
+	scoreWeightBrightness = 0.2
+	scoreWeightContrast   = 0.3
+	scoreWeightSharpness  = 0.3
+	scoreWeightUniformity = 0.2
+)
+
+const (
+	// scoreMaxSide is the number of pixels along the longest side of the grid
+	// sampled from a frame when scoring it.
+	scoreMaxSide = 160
+	// scoreDarkLimit is the mean brightness below which a frame is considered
+	// black, eg a fade.
+	scoreDarkLimit = 0.08
+	// scoreHistogramBins is the number of bins used to measure uniformity.
+	scoreHistogramBins = 16
+)
+
+// FrameScore holds the measurements used to rate how well a frame works as a
+// thumbnail. Each measurement is between 0 and 1, where higher is better.
+type FrameScore struct {
+	// Brightness is highest for frames which are neither too dark nor too bright.
+	Brightness float64
+	// Contrast measures the spread of brightness values in the frame.
+	Contrast float64
+	// Sharpness measures the edge energy in the frame, which is low for
+	// blurred frames.
+	Sharpness float64
+	// Uniformity is low for frames which are mostly a single shade, such as
+	// title cards and fades.
+	Uniformity float64
+	// Total is the weighted sum of the other measurements.
+	Total float64
+}
+
+// ScoreImage rates how well the given image works as a thumbnail.
+func ScoreImage(img image.Image) FrameScore {
+	w, h, luma := lumaGrid(img, scoreMaxSide)
+	if w < 3 || h < 3 {
+		return FrameScore{}
+	}
+
+	mean := 0.0
+	hist := make([]int, scoreHistogramBins)
+	for _, l := range luma {
+		mean += l
+		bin := int(l * scoreHistogramBins)
+		if bin == scoreHistogramBins {
+			bin--
+		}
+		hist[bin]++
+	}
+	mean /= float64(len(luma))
+
+	variance := 0.0
+	for _, l := range luma {
+		variance += (l - mean) * (l - mean)
+	}
+	stddev := math.Sqrt(variance / float64(len(luma)))
+
+	edges := 0.0
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			i := y*w + x
+			lap := 4*luma[i] - luma[i-1] - luma[i+1] - luma[i-w] - luma[i+w]
+			edges += math.Abs(lap)
+		}
+	}
+	edges /= float64((w - 2) * (h - 2))
+
+	largest := 0
+	for _, n := range hist {
+		if n > largest {
+			largest = n
+		}
+	}
+
+	s := FrameScore{
+		Brightness: 1 - math.Abs(mean-0.5)*2,
+		Contrast:   math.Min(1, stddev/0.25),
+		Sharpness:  math.Min(1, edges*10),
+		Uniformity: 1 - float64(largest)/float64(len(luma)),
+	}
+	s.Total = s.Brightness*scoreWeightBrightness +
+		s.Contrast*scoreWeightContrast +
+		s.Sharpness*scoreWeightSharpness +
+		s.Uniformity*scoreWeightUniformity
+	if mean < scoreDarkLimit {
+		s.Total /= 4
+	}
+
+	return s
+}
+
+// lumaGrid samples the brightness of img in a grid which is at most 'maxSide'
+// pixels along its longest side. The width and height of the grid are returned
+// along with the brightness values, from 0 to 1, in row order.
+func lumaGrid(img image.Image, maxSide int) (int, int, []float64) {
+	b := img.Bounds()
+	step := 1
+	if b.Dx() > maxSide || b.Dy() > maxSide {
+		step = int(math.Ceil(math.Max(float64(b.Dx()), float64(b.Dy())) / float64(maxSide)))
+	}
+	w, h := b.Dx()/step, b.Dy()/step
+
+	luma := make([]float64, 0, w*h)
+	ycc, isYCbCr := img.(*image.YCbCr)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			px, py := b.Min.X+x*step, b.Min.Y+y*step
+			if isYCbCr {
+				luma = append(luma, float64(ycc.Y[ycc.YOffset(px, py)])/255)
+			} else {
+				g := color.GrayModel.Convert(img.At(px, py)).(color.Gray)
+				luma = append(luma, float64(g.Y)/255)
+			}
+		}
+	}
+
+	return w, h, luma
+}
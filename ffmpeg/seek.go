@@ -0,0 +1,69 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Seek strategies used to extract the frames of sprites.
+const (
+	// SeekFast seeks to the time of each frame separately, which only decodes
+	// the video around each frame.
+	SeekFast = "fast"
+	// SeekAccurate decodes the whole video in a single pass, and keeps one
+	// frame every interval.
+	SeekAccurate = "accurate"
+)
+
+// DefaultWorkers is the number of frames extracted at the same time when
+// FFmpeg.Workers is 0.
+const DefaultWorkers = 4
+
+// ParseSeek validates a seek strategy, and returns it in its normalized form.
+// An empty string selects SeekFast.
+func ParseSeek(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "", SeekFast:
+		return SeekFast, nil
+	case SeekAccurate:
+		return SeekAccurate, nil
+	}
+
+	return "", fmt.Errorf("Invalid seek strategy %q.", s)
+}
+
+// intervalTimes returns the time of every frame between 'start' seconds and
+// the end of the video when a frame is taken every 'interval' seconds.
+func (f *FFmpeg) intervalTimes(ctx context.Context, start float64, interval int) ([]float64, error) {
+	length, err := f.Length(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return IntervalTimes(start, length, interval), nil
+}
+
+// IntervalTimes returns the time of every frame between 'start' seconds and
+// 'length' seconds when a frame is taken every 'interval' seconds. No times are
+// returned when 'interval' is less than 1.
+func IntervalTimes(start, length float64, interval int) []float64 {
+	times := []float64{}
+	if interval < 1 {
+		return times
+	}
+	for t := start; t < length; t += float64(interval) {
+		times = append(times, t)
+	}
+
+	return times
+}
+
+// workers returns the number of frames extracted at the same time.
+func (f *FFmpeg) workers() int {
+	if f.Workers < 1 {
+		return DefaultWorkers
+	}
+	return f.Workers
+}
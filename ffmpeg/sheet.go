@@ -0,0 +1,256 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Default values for contact sheets.
+const (
+	DefaultSheetTiles     = 30
+	DefaultSheetColumns   = 5
+	DefaultSheetTileWidth = 240
+	DefaultSheetMargin    = 10
+)
+
+// MaxSheetMargin is the largest number of pixels allowed around the frames of
+// a contact sheet.
+const MaxSheetMargin = 100
+
+// CheckSheetMargin returns an error when 'margin' is negative or larger than
+// MaxSheetMargin.
+func CheckSheetMargin(margin int) error {
+	if margin < 0 || margin > MaxSheetMargin {
+		return fmt.Errorf("Invalid margin %d. Use 0 to %d pixels.", margin, MaxSheetMargin)
+	}
+	return nil
+}
+
+// CreateContactSheet creates a printable contact sheet from the video, and
+// writes it to 'outFile' in FFmpeg.Format.
+// The sheet is a grid of 'count' frames chosen the same way as FrameTimes,
+// laid out in FFmpeg.SheetColumns columns with FFmpeg.SheetMargin pixels
+// around each frame, below a header showing the name, duration, resolution,
+// codecs and size of the file. The name is FFmpeg.SheetTitle when it's set.
+// The frames are 'width' pixels wide, or DefaultSheetTileWidth when 0 is
+// given, and sized the same way as sprite frames.
+// ErrCanvasTooLarge is returned when the sheet would be larger than
+// MaxCanvasSize or MaxCanvasPixels.
+// Each frame is captioned with FFmpeg.Label, or with its timecode when the
+// label is empty. The header uses the font, size and color of the label, and
+// is drawn on FFmpeg.Background.
+func (f *FFmpeg) CreateContactSheet(ctx context.Context, count, width int, outFile string) error {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+	os.Remove(outFile)
+
+	if err := CheckSheetMargin(f.sheetMargin()); err != nil {
+		return err
+	}
+	if err := CheckLabelSize(f.LabelSize); err != nil {
+		return err
+	}
+	enc, err := f.encoder(ctx, f.Format)
+	if err != nil {
+		return err
+	}
+	m, err := f.Probe(ctx)
+	if err != nil {
+		return err
+	}
+	if m.IsImage() && !m.IsAnimated() {
+		return fmt.Errorf("Cannot create a contact sheet from still image %q.", f.Video)
+	}
+	if count < 1 {
+		count = DefaultSheetTiles
+	}
+	if width == 0 {
+		width = DefaultSheetTileWidth
+	}
+
+	caption := f.Label
+	if caption == "" {
+		caption = "{time}"
+	}
+	label, err := f.labeler(caption)
+	if err != nil {
+		return err
+	}
+	title := f.SheetTitle
+	if title == "" {
+		title = filepath.Base(f.Video)
+	}
+	lines := SheetHeader(title, m)
+	margin := f.sheetMargin()
+	header, err := f.sheetHeaderFilter(lines, margin)
+	if err != nil {
+		return err
+	}
+	filter, err := f.frameFilter(ctx, width)
+	if err != nil {
+		return err
+	}
+	stamps, err := f.FrameTimes(ctx, count)
+	if err != nil {
+		return err
+	}
+	times := make([]float64, len(stamps))
+	for i, stamp := range stamps {
+		if times[i], err = stamp.Seconds(m.Duration, m.FrameRate); err != nil {
+			return err
+		}
+	}
+
+	tmp, err := ioutil.TempDir(TempDirectory, "thumb")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	files, err := f.extractFrames(ctx, times, filter, label, tmp)
+	if err != nil {
+		return err
+	}
+	files, times = existingFrames(files, times)
+	if len(files) == 0 {
+		return fmt.Errorf("No frames extracted from video %q.", f.Video)
+	}
+	w, h, err := imageSize(files[0])
+	if err != nil {
+		return err
+	}
+
+	frames, bounds := f.SheetLayout(times, w, h, len(lines))
+	sheet, err := f.drawFrames(bounds, files, frames)
+	if err != nil {
+		return err
+	}
+
+	// The header is drawn by ffmpeg, which also does the final encoding.
+	grid := tmp + "/sheet.png"
+	err = writeImage(grid, func(w io.Writer) error {
+		return png.Encode(w, sheet)
+	})
+	if err != nil {
+		return err
+	}
+
+	args := []string{"-f", "png_pipe", "-i", grid, "-vf", header}
+	args = append(args, enc.args()...)
+	args = append(args, outFile)
+	err = run(ctx, CmdFFmpeg, args...)
+	if err != nil {
+		os.Remove(outFile)
+		return err
+	}
+
+	return nil
+}
+
+// sheetHeaderFilter returns the filter which draws the lines of the contact
+// sheet header, 'margin' pixels from the top left corner of the sheet.
+func (f *FFmpeg) sheetHeaderFilter(lines []string, margin int) (string, error) {
+	font, err := f.fontOptions()
+	if err != nil {
+		return "", err
+	}
+
+	filters := make([]string, len(lines))
+	for i, line := range lines {
+		opts := []string{
+			"text=" + filterQuote(line),
+			"expansion=none",
+		}
+		opts = append(opts, font...)
+		opts = append(opts, "x="+strconv.Itoa(margin), "y="+strconv.Itoa(margin+i*f.sheetLineHeight()))
+		filters[i] = "drawtext=" + strings.Join(opts, ":")
+	}
+
+	return strings.Join(filters, ","), nil
+}
+
+// SheetLayout returns the positions of frames 'w' by 'h' pixels taken at the
+// given times in a contact sheet, below a header of 'lines' lines, along with
+// the bounds of the whole sheet. The frames are laid out in Options.SheetColumns
+// columns with Options.SheetMargin pixels around each frame.
+func (o Options) SheetLayout(times []float64, w, h, lines int) ([]SpriteFrame, image.Rectangle) {
+	margin := o.sheetMargin()
+	columns := o.SheetColumns
+	if columns < 1 {
+		columns = DefaultSheetColumns
+	}
+	cols, rows := Layout{Columns: columns}.Grid(len(times))
+	top := margin + lines*o.sheetLineHeight()
+
+	frames := make([]SpriteFrame, len(times))
+	for i, t := range times {
+		frames[i] = SpriteFrame{
+			Index:  i + 1,
+			Time:   t,
+			X:      margin + (i%cols)*(w+margin),
+			Y:      top + margin + (i/cols)*(h+margin),
+			Width:  w,
+			Height: h,
+		}
+	}
+
+	return frames, image.Rect(0, 0, margin+cols*(w+margin), top+margin+rows*(h+margin))
+}
+
+// sheetLineHeight returns the height in pixels of each line in the contact
+// sheet header.
+func (o Options) sheetLineHeight() int {
+	return o.labelSize() * 3 / 2
+}
+
+// sheetMargin returns Options.SheetMargin, or DefaultSheetMargin when it's negative.
+func (o Options) sheetMargin() int {
+	if o.SheetMargin < 0 {
+		return DefaultSheetMargin
+	}
+	return o.SheetMargin
+}
+
+// SheetHeader returns the lines of the contact sheet header for the file
+// named 'name' with the given metadata.
+func SheetHeader(name string, m *Metadata) []string {
+	width, height := m.Width, m.Height
+	if m.Rotation == 90 || m.Rotation == 270 {
+		width, height = height, width
+	}
+	audio := m.AudioCodec
+	if audio == "" {
+		audio = "none"
+	}
+
+	return []string{
+		name,
+		fmt.Sprintf("Duration: %s   Resolution: %dx%d   Size: %s", FormatTime(m.Duration), width, height, formatFileSize(m.Size)),
+		fmt.Sprintf("Video: %s   Audio: %s", m.VideoCodec, audio),
+	}
+}
+
+// formatFileSize converts a number of bytes into a human readable size, eg "1.5 MB".
+func formatFileSize(size int64) string {
+	if size < 1024 {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	units := []string{"KB", "MB", "GB", "TB"}
+	value := float64(size) / 1024
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
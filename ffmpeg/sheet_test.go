@@ -0,0 +1,133 @@
+package ffmpeg
+
+import (
+	"image"
+	"reflect"
+	"testing"
+)
+
+func TestSheetHeader(t *testing.T) {
+	m := &Metadata{
+		Duration:   90.5,
+		Width:      1920,
+		Height:     1080,
+		Rotation:   90,
+		VideoCodec: "h264",
+		Size:       3 * 1024 * 1024 / 2,
+	}
+
+	want := []string{
+		"clip.mp4",
+		"Duration: 00:01:30.500   Resolution: 1080x1920   Size: 1.5 MB",
+		"Video: h264   Audio: none",
+	}
+	if got := SheetHeader("clip.mp4", m); !reflect.DeepEqual(got, want) {
+		t.Errorf("SheetHeader() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatFileSize(t *testing.T) {
+	tests := map[int64]string{
+		0:                  "0 B",
+		1023:               "1023 B",
+		1536:               "1.5 KB",
+		5 * 1024 * 1024:    "5.0 MB",
+		1024 * 1024 * 1024: "1.0 GB",
+	}
+
+	for size, want := range tests {
+		if got := formatFileSize(size); got != want {
+			t.Errorf("formatFileSize(%d) = %q, want %q", size, got, want)
+		}
+	}
+}
+
+func TestSheetHeaderFilter(t *testing.T) {
+	f := New("video.mp4")
+	f.LabelSize = 20
+
+	want := `drawtext=text='a.mp4':expansion=none:fontsize=20:fontcolor=0xffffff@1.00:x=8:y=8,` +
+		`drawtext=text='Video\: h264':expansion=none:fontsize=20:fontcolor=0xffffff@1.00:x=8:y=38`
+	got, err := f.sheetHeaderFilter([]string{"a.mp4", "Video: h264"}, 8)
+	if err != nil || got != want {
+		t.Errorf("sheetHeaderFilter() = %q, %v, want %q", got, err, want)
+	}
+}
+
+func TestSheetLayout(t *testing.T) {
+	o := DefaultOptions()
+	o.SheetColumns = 3
+	o.SheetMargin = 10
+	o.LabelSize = 20
+
+	frames, bounds := o.SheetLayout([]float64{5, 15, 25, 35}, 160, 90, 3)
+	// The header is 3 lines of 30 pixels below the margin, so the frames
+	// begin at 10+90+10 pixels.
+	if bounds != image.Rect(0, 0, 520, 310) {
+		t.Errorf("bounds = %v, want (0,0)-(520,310)", bounds)
+	}
+	want := []SpriteFrame{
+		{Index: 1, Time: 5, X: 10, Y: 110, Width: 160, Height: 90},
+		{Index: 2, Time: 15, X: 180, Y: 110, Width: 160, Height: 90},
+		{Index: 3, Time: 25, X: 350, Y: 110, Width: 160, Height: 90},
+		{Index: 4, Time: 35, X: 10, Y: 210, Width: 160, Height: 90},
+	}
+	if !reflect.DeepEqual(frames, want) {
+		t.Errorf("frames = %+v, want %+v", frames, want)
+	}
+}
+
+func TestSheetLayoutDefaults(t *testing.T) {
+	o := DefaultOptions()
+	o.SheetColumns = 0
+	o.SheetMargin = -1
+	o.LabelSize = 0
+
+	frames, bounds := o.SheetLayout(make([]float64, 7), 100, 50, 3)
+	top := DefaultSheetMargin + 3*DefaultLabelSize*3/2
+	wantWidth := DefaultSheetMargin + DefaultSheetColumns*(100+DefaultSheetMargin)
+	wantHeight := top + DefaultSheetMargin + 2*(50+DefaultSheetMargin)
+	if bounds.Dx() != wantWidth || bounds.Dy() != wantHeight {
+		t.Errorf("bounds = %v, want %dx%d", bounds, wantWidth, wantHeight)
+	}
+	if last := frames[6]; last.X != DefaultSheetMargin+100+DefaultSheetMargin || last.Y != top+DefaultSheetMargin+50+DefaultSheetMargin {
+		t.Errorf("frames[6] = %+v", last)
+	}
+}
+
+func TestCheckSheetMargin(t *testing.T) {
+	for _, margin := range []int{0, DefaultSheetMargin, MaxSheetMargin} {
+		if err := CheckSheetMargin(margin); err != nil {
+			t.Errorf("CheckSheetMargin(%d) error: %s", margin, err)
+		}
+	}
+	for _, margin := range []int{-1, MaxSheetMargin + 1, 200000} {
+		if err := CheckSheetMargin(margin); err == nil {
+			t.Errorf("CheckSheetMargin(%d) did not return an error", margin)
+		}
+	}
+}
+
+func TestCheckCanvas(t *testing.T) {
+	tests := []struct {
+		bounds image.Rectangle
+		valid  bool
+	}{
+		{image.Rect(0, 0, 1200, 2000), true},
+		{image.Rect(0, 0, MaxCanvasSize, 100), true},
+		{image.Rect(0, 0, MaxCanvasSize+1, 100), false},
+		{image.Rect(0, 0, 100, MaxCanvasSize+1), false},
+		{image.Rect(0, 0, 8000, 8000), false},
+		{image.Rect(0, 0, 400010, 400010), false},
+	}
+
+	for _, test := range tests {
+		err := CheckCanvas(test.bounds)
+		if test.valid && err != nil {
+			t.Errorf("CheckCanvas(%v) error: %s", test.bounds, err)
+		}
+		if !test.valid && err != ErrCanvasTooLarge {
+			t.Errorf("CheckCanvas(%v) error = %v, want ErrCanvasTooLarge", test.bounds, err)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package ffmpeg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// Sprite describes the layout of a sprite written by CreateThumbnailSprite.
+type Sprite struct {
+	// Width is the width of the whole sprite in pixels.
+	Width int `json:"width"`
+	// Height is the height of the whole sprite in pixels.
+	Height int `json:"height"`
+	// TileWidth is the width of each frame in pixels.
+	TileWidth int `json:"tile_width"`
+	// TileHeight is the height of each frame in pixels.
+	TileHeight int `json:"tile_height"`
+	// Columns is the number of frames in each row.
+	Columns int `json:"columns"`
+	// Rows is the number of rows.
+	Rows int `json:"rows"`
+	// Layout is the layout the frames were arranged with, eg "5x".
+	Layout string `json:"layout"`
+	// Padding is the number of pixels between the frames.
+	Padding int `json:"padding"`
+	// Interval is the number of seconds between the frames. It's 0 when the
+	// frames are scene changes, or the frames of an animated image.
+	Interval int `json:"interval"`
+	// Frames lists the frames in the order they appear in the sprite.
+	Frames []SpriteFrame `json:"frames"`
+}
+
+// SpriteFrame describes the position of a single frame inside a sprite.
+type SpriteFrame struct {
+	// Index is the position of the frame in the sprite, starting at 1.
+	Index int `json:"index"`
+	// Time is the position of the frame in the video, in seconds.
+	Time   float64 `json:"time"`
+	X      int     `json:"x"`
+	Y      int     `json:"y"`
+	Width  int     `json:"width"`
+	Height int     `json:"height"`
+}
+
+// SpriteManifest is the JSON document describing a sprite image.
+type SpriteManifest struct {
+	// Image is the location of the sprite image, such as its file name. It's
+	// empty when the image is embedded.
+	Image string `json:"image,omitempty"`
+	// Type is the mime type of the sprite image.
+	Type string `json:"type"`
+	// Data is the base64 encoded sprite image when it's embedded.
+	Data string `json:"data,omitempty"`
+	Sprite
+}
+
+// MaxPadding is the largest number of pixels allowed between sprite frames.
+const MaxPadding = 100
+
+// CheckPadding returns an error when 'padding' is negative or larger than
+// MaxPadding.
+func CheckPadding(padding int) error {
+	if padding < 0 || padding > MaxPadding {
+		return fmt.Errorf("Invalid sprite padding %d. Use 0 to %d pixels.", padding, MaxPadding)
+	}
+	return nil
+}
+
+// NewSprite returns the layout of a sprite made from frames 'w' by 'h' pixels
+// taken at the given times, arranged using 'layout' with 'padding' pixels
+// between them. Times beyond the Limit of the layout are left out.
+func NewSprite(times []float64, w, h int, layout Layout, padding, interval int) Sprite {
+	times = times[:layout.Limit(len(times))]
+	cols, rows := layout.Grid(len(times))
+	frames := make([]SpriteFrame, len(times))
+	for i, t := range times {
+		frames[i] = SpriteFrame{
+			Index:  i + 1,
+			Time:   t,
+			X:      (i % cols) * (w + padding),
+			Y:      (i / cols) * (h + padding),
+			Width:  w,
+			Height: h,
+		}
+	}
+
+	return Sprite{
+		Width:      cols*w + (cols-1)*padding,
+		Height:     rows*h + (rows-1)*padding,
+		TileWidth:  w,
+		TileHeight: h,
+		Columns:    cols,
+		Rows:       rows,
+		Layout:     layout.String(),
+		Padding:    padding,
+		Interval:   interval,
+		Frames:     frames,
+	}
+}
+
+// WriteManifest writes a JSON manifest of the sprite to 'file', which refers
+// to the sprite image as 'image'.
+func (s Sprite) WriteManifest(file, image string, format Format) error {
+	data, err := json.MarshalIndent(SpriteManifest{
+		Image:  image,
+		Type:   format.MimeType(),
+		Sprite: s,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, append(data, '\n'), 0644)
+}
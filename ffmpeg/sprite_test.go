@@ -0,0 +1,144 @@
+package ffmpeg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewSprite(t *testing.T) {
+	s := NewSprite([]float64{0, 10, 20, 30, 40}, 160, 90, Layout{Columns: 2}, 4, 10)
+
+	if s.Width != 324 || s.Height != 278 || s.Columns != 2 || s.Rows != 3 || s.Layout != "2x" {
+		t.Errorf("sprite = %dx%d in %dx%d %q, want 324x278 in 2x3 \"2x\"", s.Width, s.Height, s.Columns, s.Rows, s.Layout)
+	}
+	want := SpriteFrame{Index: 4, Time: 30, X: 164, Y: 94, Width: 160, Height: 90}
+	if s.Frames[3] != want {
+		t.Errorf("Frames[3] = %+v, want %+v", s.Frames[3], want)
+	}
+}
+
+func TestNewSpriteLimit(t *testing.T) {
+	times := []float64{0, 10, 20, 30, 40, 50, 60}
+	s := NewSprite(times, 160, 90, Layout{Columns: 3, Rows: 2}, 0, 10)
+
+	if len(s.Frames) != 6 || s.Columns != 3 || s.Rows != 2 || s.Width != 480 || s.Height != 180 {
+		t.Errorf("sprite = %d frames %dx%d in %dx%d, want 6 frames 480x180 in 3x2", len(s.Frames), s.Width, s.Height, s.Columns, s.Rows)
+	}
+}
+
+func TestCheckPadding(t *testing.T) {
+	for _, padding := range []int{0, 4, MaxPadding} {
+		if err := CheckPadding(padding); err != nil {
+			t.Errorf("CheckPadding(%d) error: %s", padding, err)
+		}
+	}
+	for _, padding := range []int{-1, MaxPadding + 1} {
+		if err := CheckPadding(padding); err == nil {
+			t.Errorf("CheckPadding(%d) did not return an error", padding)
+		}
+	}
+}
+
+func TestSpriteWriteManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "sprite.json")
+
+	s := NewSprite([]float64{0, 5}, 100, 50, Layout{}, 0, 5)
+	if err := s.WriteManifest(file, "sprite.webp", FormatWebP); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["image"] != "sprite.webp" || m["type"] != "image/webp" || m["tile_width"] != 100.0 || m["width"] != 200.0 {
+		t.Errorf("manifest = %s", data)
+	}
+	if _, ok := m["data"]; ok {
+		t.Error("manifest has embedded data, want only a reference to the image")
+	}
+}
+
+func TestCreateThumbnailSpriteInvalidInterval(t *testing.T) {
+	for _, interval := range []int{0, -5} {
+		_, err := New("video.mp4").CreateThumbnailSprite(context.Background(), interval, 180, "sprite.jpg")
+		if err == nil || !strings.Contains(err.Error(), "interval") {
+			t.Errorf("CreateThumbnailSprite(%d) error = %v, want an invalid interval error", interval, err)
+		}
+	}
+}
+
+func TestVTTTrack(t *testing.T) {
+	frames := []SpriteFrame{
+		{Index: 1, Time: 0, X: 0, Y: 0, Width: 160, Height: 90},
+		{Index: 2, Time: 7.5, X: 160, Y: 0, Width: 160, Height: 90},
+		{Index: 3, Time: 15, X: 0, Y: 90, Width: 160, Height: 90},
+	}
+
+	want := "WEBVTT\n" +
+		"\n00:00:00.000 --> 00:00:07.500\nsprite.jpg#xywh=0,0,160,90\n" +
+		"\n00:00:07.500 --> 00:00:15.000\nsprite.jpg#xywh=160,0,160,90\n" +
+		"\n00:00:15.000 --> 00:00:25.000\nsprite.jpg#xywh=0,90,160,90\n"
+	if got := VTTTrack(frames, 10, "sprite.jpg"); got != want {
+		t.Errorf("VTTTrack() = %q, want %q", got, want)
+	}
+}
+
+func TestSpriteIntervalFor(t *testing.T) {
+	tests := []struct {
+		remaining float64
+		count     int
+		want      int
+	}{
+		{60, 30, 2},
+		{60, 7, 8},
+		{60, 0, 60},
+		{10, 30, 1},
+		{1.5, 30, 1},
+		{0.5, 30, 0},
+		{-5, 30, 0},
+	}
+
+	for _, test := range tests {
+		if got := SpriteIntervalFor(test.remaining, test.count); got != test.want {
+			t.Errorf("SpriteIntervalFor(%v, %d) = %d, want %d", test.remaining, test.count, got, test.want)
+		}
+	}
+}
+
+func TestIntervalTimes(t *testing.T) {
+	tests := []struct {
+		start, length float64
+		interval      int
+		want          []float64
+	}{
+		{0, 10, 3, []float64{0, 3, 6, 9}},
+		{2.5, 10, 5, []float64{2.5, 7.5}},
+		{0, 9, 3, []float64{0, 3, 6}},
+		{12, 10, 1, []float64{}},
+		{0, 10, 0, []float64{}},
+		{0, 10, -1, []float64{}},
+	}
+
+	for _, test := range tests {
+		got := IntervalTimes(test.start, test.length, test.interval)
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("IntervalTimes(%v, %v, %d) = %v, want %v", test.start, test.length, test.interval, got, test.want)
+		}
+	}
+}
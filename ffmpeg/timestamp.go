@@ -0,0 +1,160 @@
+package ffmpeg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrTimestampOutOfRange is returned when a timestamp falls outside of the video.
+var ErrTimestampOutOfRange = errors.New("Timestamp out of range")
+
+// Units a Timestamp may be given in.
+const (
+	unitSeconds = iota
+	unitPercent
+	unitFrame
+)
+
+// Timestamp is a position in a video.
+// It may be given in seconds, as a timecode, as a percentage of the video
+// duration, or as a frame number. Percentages and frame numbers are converted
+// to seconds using the probed duration and frame rate of the video.
+type Timestamp struct {
+	value float64
+	unit  int
+}
+
+// Seconds returns a Timestamp for the given number of seconds.
+func Seconds(secs float64) Timestamp {
+	return Timestamp{value: secs, unit: unitSeconds}
+}
+
+// ParseTimestamp converts a string into a Timestamp.
+// The string may be given in one of these formats:
+//
+//	90        seconds
+//	90.5      fractional seconds
+//	01:30     minutes and seconds
+//	00:01:30.500  hours, minutes and seconds
+//	25%       percentage of the video duration
+//	1200f     frame number
+//
+// An empty string is the start of the video.
+func ParseTimestamp(s string) (Timestamp, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case s == "":
+		return Timestamp{}, nil
+	case strings.HasSuffix(s, "%"):
+		p, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if err != nil || math.IsNaN(p) || p < 0 || p > 100 {
+			return Timestamp{}, fmt.Errorf("Invalid percentage timestamp %q.", s)
+		}
+		return Timestamp{value: p, unit: unitPercent}, nil
+	case strings.HasSuffix(s, "f"):
+		n, err := strconv.ParseUint(strings.TrimSuffix(s, "f"), 10, 32)
+		if err != nil {
+			return Timestamp{}, fmt.Errorf("Invalid frame number timestamp %q.", s)
+		}
+		return Timestamp{value: float64(n), unit: unitFrame}, nil
+	case strings.Contains(s, ":"):
+		return parseTimecode(s)
+	}
+
+	secs, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(secs) || secs < 0 || math.IsInf(secs, 0) {
+		return Timestamp{}, fmt.Errorf("Invalid timestamp %q.", s)
+	}
+	return Seconds(secs), nil
+}
+
+// ParseTimestamps converts a comma separated list of timestamps into an array
+// of Timestamp values. Each timestamp may use any format accepted by
+// ParseTimestamp.
+func ParseTimestamps(s string) ([]Timestamp, error) {
+	parts := strings.Split(s, ",")
+	times := make([]Timestamp, len(parts))
+	for i, part := range parts {
+		t, err := ParseTimestamp(part)
+		if err != nil {
+			return nil, err
+		}
+		times[i] = t
+	}
+
+	return times, nil
+}
+
+// parseTimecode converts a timecode in the format "HH:MM:SS.mmm" or "MM:SS.mmm"
+// into a Timestamp.
+func parseTimecode(s string) (Timestamp, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return Timestamp{}, fmt.Errorf("Invalid timecode %q.", s)
+	}
+
+	secs, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil || math.IsNaN(secs) || secs < 0 || secs >= 60 {
+		return Timestamp{}, fmt.Errorf("Invalid timecode %q.", s)
+	}
+	mult := 60.0
+	for i := len(parts) - 2; i >= 0; i-- {
+		n, err := strconv.ParseUint(parts[i], 10, 32)
+		if err != nil || (i > 0 && n >= 60) {
+			return Timestamp{}, fmt.Errorf("Invalid timecode %q.", s)
+		}
+		secs += float64(n) * mult
+		mult *= 60
+	}
+
+	return Seconds(secs), nil
+}
+
+// IsZero returns whether the timestamp is the start of the video.
+func (t Timestamp) IsZero() bool {
+	return t.value == 0
+}
+
+// Seconds converts the timestamp into seconds for a video with the given
+// duration and frame rate. An error wrapping ErrTimestampOutOfRange is
+// returned when the timestamp is not before the end of the video.
+func (t Timestamp) Seconds(duration, frameRate float64) (float64, error) {
+	secs := t.value
+	switch t.unit {
+	case unitPercent:
+		secs = duration * t.value / 100
+	case unitFrame:
+		if frameRate <= 0 {
+			return 0, fmt.Errorf("Cannot use frame number %s without the video frame rate.", t)
+		}
+		secs = t.value / frameRate
+	}
+
+	if secs > 0 && secs >= duration {
+		return 0, fmt.Errorf("%w: %s is not before the end of the video at %s.",
+			ErrTimestampOutOfRange, t, FormatTime(duration))
+	}
+
+	return secs, nil
+}
+
+// String implements fmt.Stringer.
+func (t Timestamp) String() string {
+	switch t.unit {
+	case unitPercent:
+		return strconv.FormatFloat(t.value, 'f', -1, 64) + "%"
+	case unitFrame:
+		return strconv.FormatFloat(t.value, 'f', 0, 64) + "f"
+	}
+
+	return FormatTime(t.value)
+}
+
+// FormatTime converts seconds into the "00:00:00.000" format.
+func FormatTime(secs float64) string {
+	millis := int(math.Floor(secs*1000 + 0.5))
+	return fmt.Sprintf("%s.%.3d", SecondsToTime(millis/1000), millis%1000)
+}
@@ -0,0 +1,51 @@
+package ffmpeg
+
+import "testing"
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		s    string
+		want float64
+	}{
+		{"", 0},
+		{"90.5", 90.5},
+		{"01:30", 90},
+		{"00:01:30.500", 90.5},
+		{"25%", 30},
+		{"250f", 10},
+	}
+
+	for _, test := range tests {
+		ts, err := ParseTimestamp(test.s)
+		if err != nil {
+			t.Errorf("ParseTimestamp(%q) error = %v", test.s, err)
+			continue
+		}
+		if got, err := ts.Seconds(120, 25); err != nil || got != test.want {
+			t.Errorf("ParseTimestamp(%q).Seconds() = %v, %v, want %v", test.s, got, err, test.want)
+		}
+	}
+}
+
+func TestParseTimestampInvalid(t *testing.T) {
+	invalid := []string{
+		"soon",
+		"-5",
+		"inf",
+		"nan",
+		"NaN",
+		"nan%",
+		"101%",
+		"-1%",
+		"00:nan",
+		"00:61",
+		"1.5f",
+		"1:2:3:4",
+	}
+
+	for _, s := range invalid {
+		if ts, err := ParseTimestamp(s); err == nil {
+			t.Errorf("ParseTimestamp(%q) = %v, want an error", s, ts)
+		}
+	}
+}
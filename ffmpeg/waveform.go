@@ -0,0 +1,181 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+)
+
+// Default values for waveform images.
+const (
+	DefaultWaveformWidth  = 800
+	DefaultWaveformHeight = 200
+)
+
+// ErrNoAudioStream is returned when a waveform is requested from a file which
+// does not contain an audio stream.
+var ErrNoAudioStream = errors.New("The file does not contain an audio stream.")
+
+// AudioStream returns the first audio stream in the file, and whether there is one.
+func (m *Metadata) AudioStream() (Stream, bool) {
+	for _, s := range m.Streams {
+		if s.Type == "audio" {
+			return s, true
+		}
+	}
+	return Stream{}, false
+}
+
+// CoverArt returns the first picture embedded in the file, such as the cover
+// of an album, and whether there is one.
+func (m *Metadata) CoverArt() (Stream, bool) {
+	for _, s := range m.Streams {
+		if s.Type == "video" && s.AttachedPic {
+			return s, true
+		}
+	}
+	return Stream{}, false
+}
+
+// CreateWaveform draws the waveform of the first audio stream in the file, and
+// writes it to 'outFile' in FFmpeg.Format. The image is 'width' by
+// FFmpeg.Height pixels, or DefaultWaveformWidth by DefaultWaveformHeight when
+// they are 0. The wave is drawn in FFmpeg.WaveColor on FFmpeg.WaveBackground.
+// When FFmpeg.CoverArt is true and the file has an embedded picture, the
+// picture is written instead, sized the same way as CreateThumbnail.
+// Unlike the other thumbnails, the file does not need a video stream.
+func (f *FFmpeg) CreateWaveform(ctx context.Context, width int, outFile string) error {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+	os.Remove(outFile)
+
+	if err := CheckSize(width, f.Height); err != nil {
+		return err
+	}
+	enc, err := f.encoder(ctx, f.Format)
+	if err != nil {
+		return err
+	}
+	m, err := f.probeStreams(ctx)
+	if err != nil {
+		return err
+	}
+
+	if f.CoverArt {
+		if s, ok := m.CoverArt(); ok {
+			return f.extractCoverArt(ctx, s, width, outFile, enc)
+		}
+		f.logf("File %q has no cover art, drawing its waveform instead.", f.Video)
+	}
+
+	audio, ok := m.AudioStream()
+	if !ok {
+		return ErrNoAudioStream
+	}
+	wave, err := ParseColor(f.WaveColor)
+	if err != nil {
+		return err
+	}
+	bg, err := ParseColor(f.WaveBackground)
+	if err != nil {
+		return err
+	}
+	if width == 0 {
+		width = DefaultWaveformWidth
+	}
+	height := f.Height
+	if height == 0 {
+		height = DefaultWaveformHeight
+	}
+
+	args := []string{
+		"-i",
+		f.Video,
+		"-filter_complex",
+		waveformGraph(audio.Index, width, height, wave, bg),
+		"-map",
+		"[out]",
+		"-frames:v",
+		"1",
+	}
+	args = append(args, enc.args()...)
+	args = append(args, outFile)
+
+	err = run(ctx, CmdFFmpeg, args...)
+	if err != nil {
+		os.Remove(outFile)
+		return err
+	}
+
+	return nil
+}
+
+// waveformGraph returns the ffmpeg filter graph which draws the waveform of the
+// stream with the given index onto a background, labeled "[out]". The
+// channels are mixed down to a single wave.
+func waveformGraph(stream, width, height int, wave, bg color.RGBA) string {
+	return fmt.Sprintf(
+		"[0:%d]aformat=channel_layouts=mono,showwavespic=s=%dx%d:colors=%s[wave];"+
+			"color=c=%s:s=%dx%d[bg];[bg][wave]overlay=format=auto:shortest=1[out]",
+		stream,
+		width,
+		height,
+		hexColor(wave),
+		hexColor(bg),
+		width,
+		height)
+}
+
+// extractCoverArt writes the embedded picture 's' to 'outFile' using the given
+// encoder, sized the same way as CreateThumbnail.
+func (f *FFmpeg) extractCoverArt(ctx context.Context, s Stream, width int, outFile string, enc encoder) error {
+	filter, err := f.sizeFilter(width)
+	if err != nil {
+		return err
+	}
+	f.logf("Writing the cover art of %q.", f.Video)
+
+	args := []string{
+		"-i",
+		f.Video,
+		"-map",
+		fmt.Sprintf("0:%d", s.Index),
+		"-frames:v",
+		"1",
+	}
+	if filter != "" {
+		args = append(args, "-vf", filter)
+	}
+	args = append(args, enc.args()...)
+	args = append(args, outFile)
+
+	err = run(ctx, CmdFFmpeg, args...)
+	if err != nil {
+		os.Remove(outFile)
+		return err
+	}
+
+	return nil
+}
+
+// probeStreams returns the metadata of the file like Probe, but also succeeds
+// for files without a video stream, such as music and podcasts.
+func (f *FFmpeg) probeStreams(ctx context.Context) (*Metadata, error) {
+	if f.metadata != nil {
+		return f.metadata, nil
+	}
+
+	m, err := Probe(ctx, f.Video)
+	if err != nil && !errors.Is(err, ErrNoVideoStream) {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// hexColor returns the color in the hex format used by ffmpeg filters.
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("0x%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,37 @@
+package ffmpeg
+
+import (
+	"errors"
+	"image/color"
+	"testing"
+)
+
+func TestAudioStreams(t *testing.T) {
+	output := `{
+		"format": {"format_name": "mp3", "duration": "215.3"},
+		"streams": [
+			{"index": 0, "codec_type": "audio", "codec_name": "mp3"},
+			{"index": 1, "codec_type": "video", "codec_name": "mjpeg", "disposition": {"attached_pic": 1}}
+		]
+	}`
+	m, err := parseProbeOutput([]byte(output))
+	if !errors.Is(err, ErrNoVideoStream) {
+		t.Fatalf("parseProbeOutput() error = %v, want ErrNoVideoStream", err)
+	}
+
+	if s, ok := m.AudioStream(); !ok || s.Index != 0 {
+		t.Errorf("AudioStream() = %d, %v, want stream 0", s.Index, ok)
+	}
+	if s, ok := m.CoverArt(); !ok || s.Index != 1 {
+		t.Errorf("CoverArt() = %d, %v, want stream 1", s.Index, ok)
+	}
+}
+
+func TestWaveformGraph(t *testing.T) {
+	got := waveformGraph(2, 800, 200, color.RGBA{0xff, 0x80, 0, 0xff}, color.RGBA{0, 0, 0, 0xff})
+	want := "[0:2]aformat=channel_layouts=mono,showwavespic=s=800x200:colors=0xff8000[wave];" +
+		"color=c=0x000000:s=800x200[bg];[bg][wave]overlay=format=auto:shortest=1[out]"
+	if got != want {
+		t.Errorf("waveformGraph() = %q, want %q", got, want)
+	}
+}
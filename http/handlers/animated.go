@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dulo-tech/service-thumbnails/core"
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+)
+
+// AnimatedHandler is an HTTP handler for creating animated thumbnails.
+type AnimatedHandler struct {
+	Handler
+}
+
+// NewAnimated creates and returns a new AnimatedHandler instance.
+func NewAnimated() *AnimatedHandler {
+	return &AnimatedHandler{}
+}
+
+// ServeHTTP implements http.Handler.ServeHTTP.
+func (h *AnimatedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	file := getFile(w, r)
+	if file == nil {
+		return
+	}
+
+	width := DefaultAnimatedWidth
+	fps := core.Opts.FPS
+	palette := core.Opts.Palette
+	format := DefaultAnimatedFormat
+	quality := core.Opts.Quality
+
+	query := r.URL.Query()
+	if w, ok := query["width"]; ok {
+		width = atoi(w[0])
+	}
+	if f, ok := query["fps"]; ok {
+		fps = atoi(f[0])
+	}
+	if p, ok := query["palette"]; ok {
+		palette = atob(p[0])
+	}
+	if f, ok := query["format"]; ok {
+		format = f[0]
+	}
+	if q, ok := query["quality"]; ok {
+		quality = atoi(q[0])
+	}
+
+	segments, length, err := segmentParams(query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := ffmpeg.CheckSize(width, 0); err != nil {
+		writeError(w, paramError{err})
+		return
+	}
+	if fps < 0 || fps > DefaultMaxFPS {
+		writeError(w, paramError{fmt.Errorf("Invalid frame rate %d. Use 0 to %d.", fps, DefaultMaxFPS)})
+		return
+	}
+	f, err := ffmpeg.ParseFormat(format)
+	if err != nil || (f != ffmpeg.FormatGIF && f != ffmpeg.FormatWebP) {
+		writeError(w, paramError{errInvalidAnimatedFormat})
+		return
+	}
+	skip, err := skipParam(query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	temp := getTempFile()
+	opts := ffmpeg.DefaultOptions()
+	opts.Skip = skip
+	opts.Format = f
+	opts.Quality = quality
+	opts.Segments = segments
+	opts.SegmentLength = length
+	opts.FPS = fps
+	opts.Palette = palette
+
+	ff, err := newThumbnailer(file, opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = ff.CreateAnimated(r.Context(), width, temp)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	numRequests++
+	setImageHeaders(w, f)
+	writeFileToResponse(temp, w)
+}
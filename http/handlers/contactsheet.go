@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dulo-tech/service-thumbnails/core"
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+)
+
+// ContactSheetHandler is an HTTP handler for creating contact sheets.
+type ContactSheetHandler struct {
+	Handler
+}
+
+// NewContactSheet creates and returns a new ContactSheetHandler instance.
+func NewContactSheet() *ContactSheetHandler {
+	return &ContactSheetHandler{}
+}
+
+// ServeHTTP implements http.Handler.ServeHTTP.
+func (h *ContactSheetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	file := getFile(w, r)
+	if file == nil {
+		return
+	}
+
+	width := ffmpeg.DefaultSheetTileWidth
+	count := core.Opts.Count
+	columns := core.Opts.Columns
+	margin := core.Opts.Margin
+	background := core.Opts.Background
+
+	query := r.URL.Query()
+	if w, ok := query["width"]; ok {
+		width = atoi(w[0])
+	}
+	if c, ok := query["count"]; ok {
+		count = atoi(c[0])
+	}
+	if c, ok := query["columns"]; ok {
+		columns = atoi(c[0])
+	}
+	if m, ok := query["margin"]; ok {
+		margin = atoi(m[0])
+	}
+	if b, ok := query["background"]; ok {
+		background = b[0]
+	}
+	if count < 1 || count > DefaultMaxTiles {
+		writeError(w, paramError{fmt.Errorf("The number of frames must be between 1 and %d.", DefaultMaxTiles)})
+		return
+	}
+	if columns < 1 {
+		writeError(w, paramError{fmt.Errorf("Invalid number of columns %d.", columns)})
+		return
+	}
+	if err := ffmpeg.CheckSheetMargin(margin); err != nil {
+		writeError(w, paramError{err})
+		return
+	}
+	if _, err := ffmpeg.ParseColor(background); err != nil {
+		writeError(w, paramError{err})
+		return
+	}
+	format, quality, err := formatParams(query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	height, fit, padColor, err := sizeParams(query, width)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	skip, err := skipParam(query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	temp := getTempFile()
+	opts := ffmpeg.DefaultOptions()
+	opts.Skip = skip
+	opts.Background = background
+	opts.Format = format
+	opts.Quality = quality
+	opts.Height = height
+	opts.Fit = fit
+	opts.PadColor = padColor
+	opts.Workers = core.Opts.Workers
+	opts.SheetColumns = columns
+	opts.SheetMargin = margin
+	opts.SheetTitle = file.Name
+	if err := overlayParams(query, &opts); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// The frame height isn't known until the video is opened, so the size of
+	// the sheet is estimated with square frames when no height is given.
+	tileHeight := height
+	if tileHeight == 0 {
+		tileHeight = width
+	}
+	_, bounds := opts.SheetLayout(make([]float64, count), width, tileHeight, 3)
+	if err := ffmpeg.CheckCanvas(bounds); err != nil {
+		writeError(w, paramError{err})
+		return
+	}
+
+	ff, err := newThumbnailer(file, opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = ff.CreateContactSheet(r.Context(), count, width, temp)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	numRequests++
+	setImageHeaders(w, format)
+	writeFileToResponse(temp, w)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/dulo-tech/service-thumbnails/core"
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+	"github.com/dulo-tech/service-thumbnails/phash"
+)
+
+// FingerprintHandler is an HTTP handler for creating video fingerprints.
+type FingerprintHandler struct {
+	Handler
+}
+
+// NewFingerprint creates and returns a new FingerprintHandler instance.
+func NewFingerprint() *FingerprintHandler {
+	return &FingerprintHandler{}
+}
+
+// ServeHTTP implements http.Handler.ServeHTTP.
+// The fingerprint is returned as JSON.
+func (h *FingerprintHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	file := getFile(w, r)
+	if file == nil {
+		return
+	}
+
+	hash := core.Opts.Hash
+	count := core.Opts.HashFrames
+
+	query := r.URL.Query()
+	if h, ok := query["hash"]; ok {
+		hash = h[0]
+	}
+	if c, ok := query["frames"]; ok {
+		count = atoi(c[0])
+	}
+	algorithm, err := phash.ParseAlgorithm(hash)
+	if err != nil {
+		writeError(w, paramError{err})
+		return
+	}
+	if count < 1 || count > DefaultMaxHashFrames {
+		writeError(w, paramError{fmt.Errorf("Invalid number of frames %d. Use 1 to %d.", count, DefaultMaxHashFrames)})
+		return
+	}
+	opts := ffmpeg.DefaultOptions()
+	opts.Workers = core.Opts.Workers
+
+	ff, err := newThumbnailer(file, opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	fp, err := phash.FromVideo(r.Context(), ff, count, algorithm)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	numRequests++
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fp)
+}
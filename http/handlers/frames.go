@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/dulo-tech/service-thumbnails/core"
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+)
+
+// FramesHandler is an HTTP handler for creating several stills from a video.
+type FramesHandler struct {
+	Handler
+}
+
+// frameJSON is a single still in the JSON response of the frames handler.
+type frameJSON struct {
+	Index    int     `json:"index"`
+	Time     float64 `json:"time"`
+	Timecode string  `json:"timecode"`
+	Type     string  `json:"type"`
+	Data     string  `json:"data"`
+}
+
+// NewFrames creates and returns a new FramesHandler instance.
+func NewFrames() *FramesHandler {
+	return &FramesHandler{}
+}
+
+// ServeHTTP implements http.Handler.ServeHTTP.
+// The stills are returned in a zip archive, or as a JSON array with the images
+// encoded in base64 when the "output" query argument is "json".
+func (h *FramesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	file := getFile(w, r)
+	if file == nil {
+		return
+	}
+
+	width := DefaultSimpleWidth
+	times := core.Opts.Times
+	count := core.Opts.Frames
+	output := "zip"
+
+	query := r.URL.Query()
+	if w, ok := query["width"]; ok {
+		width = atoi(w[0])
+	}
+	if t, ok := query["times"]; ok {
+		times = t[0]
+	}
+	if c, ok := query["count"]; ok {
+		count = atoi(c[0])
+	}
+	if o, ok := query["output"]; ok {
+		output = o[0]
+	}
+	if output != "zip" && output != "json" {
+		writeError(w, paramError{fmt.Errorf("Invalid output %q.", output)})
+		return
+	}
+	format, quality, err := formatParams(query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	height, fit, padColor, err := sizeParams(query, width)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	skip, err := skipParam(query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	opts := ffmpeg.DefaultOptions()
+	opts.Skip = skip
+	opts.Format = format
+	opts.Quality = quality
+	opts.Height = height
+	opts.Fit = fit
+	opts.PadColor = padColor
+
+	ff, err := newThumbnailer(file, opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var stamps []ffmpeg.Timestamp
+	if times != "" {
+		stamps, err = ffmpeg.ParseTimestamps(times)
+		if err != nil {
+			writeError(w, paramError{err})
+			return
+		}
+	} else {
+		if count > DefaultMaxFrames {
+			count = DefaultMaxFrames
+		}
+		stamps, err = ff.FrameTimes(r.Context(), count)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	if len(stamps) > DefaultMaxFrames {
+		writeError(w, paramError{fmt.Errorf("No more than %d frames may be requested.", DefaultMaxFrames)})
+		return
+	}
+
+	temp, err := ioutil.TempDir("/tmp", "thumb")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer os.RemoveAll(temp)
+
+	stills, err := ff.CreateFrames(r.Context(), stamps, width, temp+"/frame{index}"+format.Ext())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	numRequests++
+	if output == "json" {
+		writeFramesJSON(stills, format, w)
+		return
+	}
+
+	files := make([]string, len(stills))
+	names := make([]string, len(stills))
+	for i, still := range stills {
+		files[i] = still.File
+		names[i] = fmt.Sprintf("frame%02d%s", i+1, format.Ext())
+	}
+	w.Header().Set("Content-Disposition", "attachment; filename=frames.zip")
+	w.Header().Set("Content-Type", "application/zip")
+	writeZipToResponse(files, names, w)
+}
+
+// writeFramesJSON writes the given stills to the http response as a JSON array.
+func writeFramesJSON(stills []ffmpeg.Still, format ffmpeg.Format, w http.ResponseWriter) error {
+	frames := make([]frameJSON, len(stills))
+	for i, still := range stills {
+		data, err := ioutil.ReadFile(still.File)
+		if err != nil {
+			numErrors++
+			return err
+		}
+		frames[i] = frameJSON{
+			Index:    i + 1,
+			Time:     still.Time,
+			Timecode: ffmpeg.FormatTime(still.Time),
+			Type:     format.MimeType(),
+			Data:     base64.StdEncoding.EncodeToString(data),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(frames)
+}
@@ -1,13 +1,22 @@
 package handlers
 
 import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/dulo-tech/service-thumbnails/core"
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+	"github.com/dulo-tech/service-thumbnails/palette"
+	"github.com/dulo-tech/service-thumbnails/placeholder"
 	"github.com/rakyll/magicmime"
 )
 
@@ -20,15 +29,51 @@ const (
 	DefaultSimpleWidth = 0
 	// Default width for sprite thumbnails.
 	DefaultSpriteWidth = 180
+	// Default width for animated thumbnails.
+	DefaultAnimatedWidth = 320
+	// Default image format for animated thumbnails.
+	DefaultAnimatedFormat = "gif"
+	// Default width for preview clips.
+	DefaultPreviewWidth = 480
+	// Max number of stills returned by the frames handler.
+	DefaultMaxFrames = 20
+	// Max number of frames in sprites and VTT sprites.
+	DefaultMaxSpriteFrames = 200
+	// Max number of frames in a contact sheet.
+	DefaultMaxTiles = 100
+	// Max number of frames hashed in a fingerprint.
+	DefaultMaxHashFrames = 64
+	// Max number of frames considered for smart simple thumbnails.
+	DefaultMaxCandidates = 32
+	// Max number of excerpts in animations and preview clips.
+	DefaultMaxSegments = 20
+	// Max length in seconds of each excerpt in animations and preview clips.
+	DefaultMaxSegmentLength = 10.0
+	// Max frame rate of animations.
+	DefaultMaxFPS = 30
 )
 
 var (
+	// errInvalidAnimatedFormat is returned when an animated thumbnail is
+	// requested in a format other than gif or webp.
+	errInvalidAnimatedFormat = errors.New("Animated thumbnails must be gif or webp.")
+
 	// numRequests counts the number of total requests handled by the http server.
 	numRequests int = 0
 
 	// numErrors counts the number of errors generated by the http server.
 	numErrors int = 0
 
+	// supportedMimeTypes are the mime types, other than video/*, image/* and
+	// audio/*, of files which may be videos.
+	supportedMimeTypes = []string{
+		DefaultMimeType,
+		"application/octet-stream",
+		"application/ogg",
+		"application/mxf",
+		"application/vnd.rn-realmedia",
+	}
+
 	// pulseIPWhiteList is a list of ip masks allowed to access the pulse end point.
 	pulseIPWhiteList = []string{
 		"127.*",
@@ -49,6 +94,11 @@ type Upload struct {
 	Temp string
 }
 
+// paramError is an error caused by an invalid query argument.
+type paramError struct {
+	error
+}
+
 // Handler is the default HTTP handler.
 type Handler struct {
 }
@@ -83,10 +133,38 @@ func getFile(w http.ResponseWriter, r *http.Request) *Upload {
 		return nil
 	}
 
+	if !isSupportedMimeType(files[0].MimeType) {
+		numErrors++
+		os.Remove(files[0].Temp)
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		w.Write([]byte(fmt.Sprintf("Unsupported file type %q. Upload a video, an image or an audio file.", files[0].MimeType)))
+		return nil
+	}
+
 	core.VPrintf("Got upload %#v\n", files[0])
 	return &files[0]
 }
 
+// isSupportedMimeType returns whether files with the given mime type may be
+// videos, images or audio. Files with an unknown type are handed to ffmpeg, which
+// has the final say.
+func isSupportedMimeType(mimeType string) bool {
+	mimeType = strings.TrimSpace(strings.SplitN(mimeType, ";", 2)[0])
+	if mimeType == "image/svg+xml" {
+		return false
+	}
+	if strings.HasPrefix(mimeType, "video/") || strings.HasPrefix(mimeType, "image/") || strings.HasPrefix(mimeType, "audio/") {
+		return true
+	}
+	for _, t := range supportedMimeTypes {
+		if mimeType == t {
+			return true
+		}
+	}
+
+	return false
+}
+
 // writeUploadedFiles writes all uploaded files to the temp dir.
 func writeUploadedFiles(r *http.Request) ([]Upload, error) {
 	if err := r.ParseMultipartForm(DefaultMaxMemory); err != nil {
@@ -129,6 +207,295 @@ func writeUploadedFiles(r *http.Request) ([]Upload, error) {
 	return files, nil
 }
 
+// writeError writes the given error to the http response.
+// The status code is chosen based on the cause of the error.
+func writeError(w http.ResponseWriter, err error) {
+	numErrors++
+	w.WriteHeader(errorStatusCode(err))
+	w.Write([]byte(err.Error()))
+}
+
+// errorStatusCode returns the http status code for the given error.
+func errorStatusCode(err error) int {
+	var pe paramError
+	switch {
+	case errors.As(err, &pe), errors.Is(err, ffmpeg.ErrUnsupportedFormat), errors.Is(err, ffmpeg.ErrTimestampOutOfRange),
+		errors.Is(err, ffmpeg.ErrCanvasTooLarge):
+		return http.StatusBadRequest
+	case ffmpeg.IsNoVideoStream(err), ffmpeg.IsInvalidInput(err), errors.Is(err, ffmpeg.ErrNoAudioStream):
+		return http.StatusUnprocessableEntity
+	case ffmpeg.IsTimeout(err):
+		return http.StatusGatewayTimeout
+	case ffmpeg.IsCanceled(err):
+		return http.StatusServiceUnavailable
+	}
+
+	return http.StatusInternalServerError
+}
+
+// formatParams returns the image format and quality chosen by the query arguments.
+// A paramError is returned when the format is invalid.
+func formatParams(query url.Values) (ffmpeg.Format, int, error) {
+	format := core.Opts.Format
+	quality := core.Opts.Quality
+	if f, ok := query["format"]; ok {
+		format = f[0]
+	}
+	if q, ok := query["quality"]; ok {
+		quality = atoi(q[0])
+	}
+
+	f, err := ffmpeg.ParseFormat(format)
+	if err != nil {
+		return "", 0, paramError{err}
+	}
+	if quality < 0 || quality > 100 {
+		return "", 0, paramError{fmt.Errorf("Invalid image quality %d.", quality)}
+	}
+
+	return f, quality, nil
+}
+
+// skipParam returns the position in the video chosen by the "skip" query
+// argument. A paramError is returned when the timestamp is invalid.
+func skipParam(query url.Values) (ffmpeg.Timestamp, error) {
+	skip := core.Opts.SkipSeconds
+	if s, ok := query["skip"]; ok {
+		skip = s[0]
+	}
+
+	t, err := ffmpeg.ParseTimestamp(skip)
+	if err != nil {
+		return ffmpeg.Timestamp{}, paramError{err}
+	}
+
+	return t, nil
+}
+
+// sizeParams returns the thumbnail height, fit mode and padding color chosen
+// by the query arguments. A paramError is returned when the fit mode is
+// invalid, or when 'width' or the height is out of range.
+func sizeParams(query url.Values, width int) (int, string, string, error) {
+	height := core.Opts.Height
+	fit := core.Opts.Fit
+	padColor := core.Opts.PadColor
+	if h, ok := query["height"]; ok {
+		height = atoi(h[0])
+	}
+	if f, ok := query["fit"]; ok {
+		fit = f[0]
+	}
+	if p, ok := query["padcolor"]; ok {
+		padColor = p[0]
+	}
+
+	if err := ffmpeg.CheckSize(width, height); err != nil {
+		return 0, "", "", paramError{err}
+	}
+	fit, err := ffmpeg.ParseFit(fit)
+	if err != nil {
+		return 0, "", "", paramError{err}
+	}
+
+	return height, fit, padColor, nil
+}
+
+// overlayParams adds the label and logo chosen by the query arguments to 'opts'.
+// The logo and label font are files on the server, so they're only taken from
+// the configuration, and the "logo" query argument only turns the logo off.
+// A paramError is returned when a position, color or size is invalid.
+func overlayParams(query url.Values, opts *ffmpeg.Options) error {
+	label := core.Opts.Label
+	labelSize := core.Opts.LabelSize
+	labelColor := core.Opts.LabelColor
+	labelBox := core.Opts.LabelBox
+	labelPos := core.Opts.LabelPos
+	logo := core.Opts.Logo != ""
+	logoPos := core.Opts.LogoPos
+	logoMargin := core.Opts.LogoMargin
+	logoOpacity := core.Opts.LogoOpacity
+	if l, ok := query["label"]; ok {
+		label = l[0]
+	}
+	if l, ok := query["labelsize"]; ok {
+		labelSize = atoi(l[0])
+	}
+	if l, ok := query["labelcolor"]; ok {
+		labelColor = l[0]
+	}
+	if l, ok := query["labelbox"]; ok {
+		labelBox = l[0]
+	}
+	if l, ok := query["labelpos"]; ok {
+		labelPos = l[0]
+	}
+	if l, ok := query["logo"]; ok {
+		logo = logo && atob(l[0])
+	}
+	if l, ok := query["logopos"]; ok {
+		logoPos = l[0]
+	}
+	if l, ok := query["logomargin"]; ok {
+		logoMargin = atoi(l[0])
+	}
+	if l, ok := query["logoopacity"]; ok {
+		logoOpacity = atof(l[0])
+	}
+
+	lp, err := ffmpeg.ParsePosition(labelPos)
+	if err != nil {
+		return paramError{err}
+	}
+	op, err := ffmpeg.ParsePosition(logoPos)
+	if err != nil {
+		return paramError{err}
+	}
+	if _, err := ffmpeg.ParseAlphaColor(labelColor); err != nil {
+		return paramError{err}
+	}
+	if labelBox != "" {
+		if _, err := ffmpeg.ParseAlphaColor(labelBox); err != nil {
+			return paramError{err}
+		}
+	}
+	if err := ffmpeg.CheckLabelSize(labelSize); err != nil {
+		return paramError{err}
+	}
+	if err := ffmpeg.CheckLogoMargin(logoMargin); err != nil {
+		return paramError{err}
+	}
+	if logoOpacity < 0 || logoOpacity > 1 {
+		return paramError{fmt.Errorf("Invalid logo opacity %v.", logoOpacity)}
+	}
+
+	opts.Label = label
+	opts.LabelFont = core.Opts.LabelFont
+	opts.LabelSize = labelSize
+	opts.LabelColor = labelColor
+	opts.LabelBox = labelBox
+	opts.LabelPosition = lp
+	if logo {
+		opts.Logo = core.Opts.Logo
+	}
+	opts.LogoPosition = op
+	opts.LogoMargin = logoMargin
+	opts.LogoOpacity = logoOpacity
+
+	return nil
+}
+
+// placeholderParams returns the placeholders and number of BlurHash components
+// chosen by the query arguments. A paramError is returned when they're invalid,
+// or when placeholders are requested for thumbnails in 'format', which cannot
+// be decoded.
+func placeholderParams(query url.Values, format ffmpeg.Format) ([]string, int, int, error) {
+	kinds := core.Opts.Placeholder
+	components := core.Opts.Components
+	if p, ok := query["placeholder"]; ok {
+		kinds = p[0]
+	}
+	if c, ok := query["components"]; ok {
+		components = c[0]
+	}
+
+	names, err := placeholder.Parse(kinds)
+	if err != nil {
+		return nil, 0, 0, paramError{err}
+	}
+	x, y, err := placeholder.ParseComponents(components)
+	if err != nil {
+		return nil, 0, 0, paramError{err}
+	}
+	if len(names) > 0 && !format.Decodable() {
+		return nil, 0, 0, paramError{fmt.Errorf("Placeholders cannot be created from %s thumbnails. Use jpeg, png or gif.", format)}
+	}
+
+	return names, x, y, nil
+}
+
+// segmentParams returns the number of excerpts and the length of each excerpt
+// chosen by the query arguments. A paramError is returned when either is out of
+// range.
+func segmentParams(query url.Values) (int, float64, error) {
+	segments := core.Opts.Segments
+	length := core.Opts.SegmentLen
+	if s, ok := query["segments"]; ok {
+		segments = atoi(s[0])
+	}
+	if l, ok := query["length"]; ok {
+		length = atof(l[0])
+	}
+
+	if segments < 0 || segments > DefaultMaxSegments {
+		return 0, 0, paramError{fmt.Errorf("Invalid number of segments %d. Use 0 to %d.", segments, DefaultMaxSegments)}
+	}
+	if !(length >= 0 && length <= DefaultMaxSegmentLength) {
+		return 0, 0, paramError{fmt.Errorf("Invalid segment length %v. Use 0 to %v seconds.", length, DefaultMaxSegmentLength)}
+	}
+
+	return segments, length, nil
+}
+
+// paletteParams returns the number of dominant colors and the number of frames
+// they're found in, chosen by the query arguments. A paramError is returned
+// when either is out of range, or when the colors would be found in a
+// thumbnail in 'format', which cannot be decoded.
+func paletteParams(query url.Values, format ffmpeg.Format) (int, int, error) {
+	colors := core.Opts.Colors
+	frames := core.Opts.ColorFrames
+	if c, ok := query["colors"]; ok {
+		colors = atoi(c[0])
+	}
+	if f, ok := query["colorframes"]; ok {
+		frames = atoi(f[0])
+	}
+
+	if colors < 0 || colors > palette.MaxColors {
+		return 0, 0, paramError{fmt.Errorf("Invalid number of colors %d. Use 0 to %d.", colors, palette.MaxColors)}
+	}
+	if frames < 0 || frames > DefaultMaxFrames {
+		return 0, 0, paramError{fmt.Errorf("Invalid number of color frames %d. Use 0 to %d.", frames, DefaultMaxFrames)}
+	}
+	if colors > 0 && frames == 0 && !format.Decodable() {
+		return 0, 0, paramError{fmt.Errorf("Colors cannot be found in %s thumbnails. Use jpeg, png or gif, or set colorframes.", format)}
+	}
+
+	return colors, frames, nil
+}
+
+// thumbnailPalette returns up to 'colors' dominant colors of the thumbnail
+// 'thumb', or of 'frames' frames of the uploaded file starting at 'skip' when
+// 'frames' is above 0.
+func thumbnailPalette(ctx context.Context, file *Upload, thumb string, skip ffmpeg.Timestamp, colors, frames int) ([]palette.Color, error) {
+	if frames == 0 {
+		return palette.FromFiles([]string{thumb}, colors)
+	}
+
+	opts := ffmpeg.DefaultOptions()
+	opts.Skip = skip
+	opts.Workers = core.Opts.Workers
+	f, err := newThumbnailer(file, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return palette.FromVideo(ctx, f, frames, colors)
+}
+
+// newThumbnailer creates a VideoThumbnailer for the uploaded file using the
+// backend option. The timeout option is added to 'opts'.
+func newThumbnailer(file *Upload, opts ffmpeg.Options) (ffmpeg.VideoThumbnailer, error) {
+	opts.Timeout = core.Opts.TimeoutDuration()
+	return ffmpeg.Open(core.Opts.Backend, file.Temp, opts)
+}
+
+// setImageHeaders sets the response headers used when returning an image in
+// the given format.
+func setImageHeaders(w http.ResponseWriter, format ffmpeg.Format) {
+	w.Header().Set("Content-Disposition", "attachment; filename=thumbnail"+format.Ext())
+	w.Header().Set("Content-Type", format.MimeType())
+}
+
 // writeFileToResponse writes a file to the http response.
 func writeFileToResponse(file string, w http.ResponseWriter) error {
 	fout, err := os.Open(file)
@@ -142,6 +509,30 @@ func writeFileToResponse(file string, w http.ResponseWriter) error {
 	return nil
 }
 
+// writeZipToResponse writes the given files to the http response as a zip archive.
+// Each file is stored in the archive using the name at the same index in 'names'.
+func writeZipToResponse(files, names []string, w http.ResponseWriter) error {
+	zw := zip.NewWriter(w)
+	for i, file := range files {
+		fin, err := os.Open(file)
+		if err != nil {
+			numErrors++
+			return err
+		}
+
+		fout, err := zw.Create(names[i])
+		if err != nil {
+			fin.Close()
+			numErrors++
+			return err
+		}
+		io.Copy(fout, fin)
+		fin.Close()
+	}
+
+	return zw.Close()
+}
+
 // getMimeType returns the file mime type.
 func getMimeType(file string) string {
 	mm, err := magicmime.New(magicmime.MAGIC_MIME_TYPE | magicmime.MAGIC_SYMLINK | magicmime.MAGIC_ERROR)
@@ -176,3 +567,21 @@ func atoi(a string) int {
 
 	return i
 }
+
+// atob converts a string to a boolean.
+// The values "1", "true" and "yes" are true, and everything else is false.
+func atob(a string) bool {
+	a = strings.ToLower(a)
+	return a == "1" || a == "true" || a == "yes"
+}
+
+// atof converts a string to a float.
+func atof(a string) float64 {
+	f, err := strconv.ParseFloat(a, 64)
+	if err != nil {
+		numErrors++
+		f = 0.0
+	}
+
+	return f
+}
@@ -0,0 +1,499 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/dulo-tech/service-thumbnails/core"
+	"github.com/dulo-tech/service-thumbnails/fake"
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+	"github.com/dulo-tech/service-thumbnails/phash"
+)
+
+// useFakeBackend switches to the fake backend, and restores the global
+// options after the test.
+func useFakeBackend(t *testing.T) {
+	saved := *core.Opts
+	t.Cleanup(func() {
+		*core.Opts = saved
+	})
+	core.Opts.Quiet = true
+	core.Opts.Backend = fake.Name
+}
+
+// mp4Header is the start of an mp4 file, which is enough for the upload to be
+// recognized as a video.
+var mp4Header = []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom")
+
+// uploadRequest returns a request which uploads a video to the given url.
+func uploadRequest(t *testing.T, url string) *http.Request {
+	return uploadFileRequest(t, url, mp4Header)
+}
+
+// uploadFileRequest returns a request which uploads a file with the given
+// contents to the given url.
+func uploadFileRequest(t *testing.T, url string, contents []byte) *http.Request {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	fw, err := mw.CreateFormFile("video", "video.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(contents)
+	mw.Close()
+
+	r := httptest.NewRequest("POST", url, body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+// serve runs the handler for the given request, and returns the response.
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// decodeSize decodes the image in the response body, and returns its size.
+func decodeSize(t *testing.T, w *httptest.ResponseRecorder) (int, int) {
+	conf, _, err := image.DecodeConfig(w.Body)
+	if err != nil {
+		t.Fatalf("Cannot decode response image: %s", err)
+	}
+	return conf.Width, conf.Height
+}
+
+func TestHandlers(t *testing.T) {
+	tests := []struct {
+		handler     http.Handler
+		url         string
+		contentType string
+		// header must be set in the response when it's not empty.
+		header string
+		// width and height are the size of the returned image, which isn't
+		// checked when they're 0.
+		width, height int
+	}{
+		{NewSimple(), "/thumbnail/simple?width=320&skip=25%25", "image/jpeg", "", 320, 180},
+		{NewSimple(), "/thumbnail/simple?smart=1&candidates=32&window=60&format=png", "image/png", "X-Thumbnail-Score", 0, 0},
+		{NewSprite(), "/thumbnail/sprite?width=64&count=6&layout=3x&padding=2", "image/jpeg", "", 3*64 + 2*2, 2*36 + 2},
+		{NewSprite(), "/thumbnail/sprite?width=64&count=6&layout=2x2", "image/jpeg", "", 2 * 64, 2 * 36},
+		{NewAnimated(), "/thumbnail/animated?width=64&segments=20&length=10&fps=30", "image/gif", "", 64, 36},
+		{NewPreview(), "/thumbnail/preview?segments=0&length=0&crf=51", "video/mp4", "Content-Disposition", 0, 0},
+		{NewWaveform(), "/thumbnail/waveform?width=400&height=100&color=%2300ff00&format=png", "image/png", "", 400, 100},
+		{NewContactSheet(), "/thumbnail/contactsheet?count=6&columns=3&width=200&margin=10&format=png", "image/png", "", 640, 336},
+	}
+
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			useFakeBackend(t)
+			w := serve(test.handler, uploadRequest(t, test.url))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != test.contentType {
+				t.Errorf("Content-Type = %q, want %q", ct, test.contentType)
+			}
+			if test.header != "" && w.Header().Get(test.header) == "" {
+				t.Errorf("response is missing the %s header", test.header)
+			}
+			if test.width == 0 {
+				return
+			}
+			if width, height := decodeSize(t, w); width != test.width || height != test.height {
+				t.Errorf("image size = %dx%d, want %dx%d", width, height, test.width, test.height)
+			}
+		})
+	}
+}
+
+func TestHandlerBadRequests(t *testing.T) {
+	tests := []struct {
+		handler http.Handler
+		url     string
+	}{
+		{NewSimple(), "/thumbnail/simple?format=bmp"},
+		{NewSimple(), "/thumbnail/simple?fit=squash"},
+		{NewSimple(), "/thumbnail/simple?skip=soon"},
+		{NewSimple(), "/thumbnail/simple?skip=90"},
+		{NewSimple(), "/thumbnail/simple?format=webp"},
+		{NewSimple(), "/thumbnail/simple?smart=1&candidates=-1"},
+		{NewSimple(), "/thumbnail/simple?smart=1&candidates=33"},
+		{NewSimple(), "/thumbnail/simple?smart=1&window=-5"},
+		{NewSimple(), "/thumbnail/simple?smart=1&window=61"},
+		{NewSimple(), "/thumbnail/simple?placeholder=pixelate"},
+		{NewSimple(), "/thumbnail/simple?placeholder=blurhash&components=10x3"},
+		{NewSimple(), "/thumbnail/simple?colors=17"},
+		{NewSimple(), "/thumbnail/simple?colors=3&colorframes=-1"},
+		{NewSimple(), "/thumbnail/simple?colors=3&colorframes=21"},
+		{NewSimple(), "/thumbnail/simple?label=x&labelpos=middle"},
+		{NewSimple(), "/thumbnail/simple?labelbox=black@2"},
+		{NewSimple(), "/thumbnail/simple?logoopacity=1.5"},
+		{NewSimple(), "/thumbnail/simple?labelsize=-1"},
+		{NewSimple(), "/thumbnail/simple?labelsize=201"},
+		{NewSimple(), "/thumbnail/simple?logomargin=-1"},
+		{NewSimple(), "/thumbnail/simple?logomargin=101"},
+		{NewSprite(), "/thumbnail/sprite?labelsize=100000"},
+		{NewSimple(), "/thumbnail/simple?height=-1"},
+		{NewSimple(), "/thumbnail/simple?width=-1"},
+		{NewSprite(), "/thumbnail/sprite?width=16000&height=16000"},
+		{NewSprite(), "/thumbnail/sprite?width=8000&height=8000&fit=stretch"},
+		{NewSprite(), "/thumbnail/sprite?count=0"},
+		{NewSprite(), "/thumbnail/sprite?count=201"},
+		{NewSprite(), "/thumbnail/sprite?count=1000000&layout=strip"},
+		{NewVTT(), "/thumbnail/vtt?count=201"},
+		{NewSprite(), "/thumbnail/sprite?count=60&width=400&layout=strip"},
+		{NewSprite(), "/thumbnail/sprite?output=xml"},
+		{NewSprite(), "/thumbnail/sprite?layout=round"},
+		{NewSprite(), "/thumbnail/sprite?padding=-1"},
+		{NewSprite(), "/thumbnail/sprite?padding=101"},
+		{NewVTT(), "/thumbnail/vtt?padding=101"},
+		{NewVTT(), "/thumbnail/vtt?height=9000"},
+		{NewAnimated(), "/thumbnail/animated?width=9000"},
+		{NewAnimated(), "/thumbnail/animated?format=png"},
+		{NewAnimated(), "/thumbnail/animated?segments=-1"},
+		{NewAnimated(), "/thumbnail/animated?segments=21"},
+		{NewAnimated(), "/thumbnail/animated?length=-1"},
+		{NewAnimated(), "/thumbnail/animated?length=10.5"},
+		{NewAnimated(), "/thumbnail/animated?length=nan"},
+		{NewAnimated(), "/thumbnail/animated?fps=-1"},
+		{NewAnimated(), "/thumbnail/animated?fps=31"},
+		{NewPreview(), "/thumbnail/preview?width=-1"},
+		{NewPreview(), "/thumbnail/preview?segments=-1"},
+		{NewPreview(), "/thumbnail/preview?segments=21"},
+		{NewPreview(), "/thumbnail/preview?length=-1"},
+		{NewPreview(), "/thumbnail/preview?length=3600"},
+		{NewPreview(), "/thumbnail/preview?crf=-1"},
+		{NewPreview(), "/thumbnail/preview?crf=52"},
+		{NewWaveform(), "/thumbnail/waveform?background=plaid"},
+		{NewWaveform(), "/thumbnail/waveform?width=20000"},
+		{NewFrames(), "/thumbnail/frames?height=9000"},
+		{NewContactSheet(), "/thumbnail/contactsheet?count=0"},
+		{NewContactSheet(), "/thumbnail/contactsheet?count=500"},
+		{NewContactSheet(), "/thumbnail/contactsheet?columns=0"},
+		{NewContactSheet(), "/thumbnail/contactsheet?margin=-1"},
+		{NewContactSheet(), "/thumbnail/contactsheet?margin=200000&count=4&columns=2"},
+		{NewContactSheet(), "/thumbnail/contactsheet?labelsize=100000"},
+		{NewContactSheet(), "/thumbnail/contactsheet?width=8000&count=100&columns=10"},
+		{NewContactSheet(), "/thumbnail/contactsheet?background=plaid"},
+		{NewContactSheet(), "/thumbnail/contactsheet?labelpos=middle"},
+		{NewFingerprint(), "/fingerprint?hash=md5"},
+		{NewFingerprint(), "/fingerprint?frames=0"},
+		{NewFingerprint(), "/fingerprint?frames=65"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			useFakeBackend(t)
+			w := serve(test.handler, uploadRequest(t, test.url))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body)
+			}
+		})
+	}
+}
+
+func TestSegmentParams(t *testing.T) {
+	tests := []struct {
+		query    string
+		segments int
+		length   float64
+		valid    bool
+	}{
+		{"", core.OptDefaultSegments, core.OptDefaultSegmentLen, true},
+		{"segments=0&length=0", 0, 0, true},
+		{"segments=20&length=10", 20, 10, true},
+		{"segments=21", 0, 0, false},
+		{"segments=-1", 0, 0, false},
+		{"length=10.5", 0, 0, false},
+		{"length=-0.5", 0, 0, false},
+		{"length=nan", 0, 0, false},
+	}
+
+	for _, test := range tests {
+		query, _ := url.ParseQuery(test.query)
+		segments, length, err := segmentParams(query)
+		if !test.valid {
+			if _, ok := err.(paramError); !ok {
+				t.Errorf("segmentParams(%q) error = %v, want a paramError", test.query, err)
+			}
+			continue
+		}
+		if err != nil || segments != test.segments || length != test.length {
+			t.Errorf("segmentParams(%q) = %d, %v, %v, want %d, %v", test.query, segments, length, err, test.segments, test.length)
+		}
+	}
+}
+
+func TestSimpleHandlerPlaceholders(t *testing.T) {
+	useFakeBackend(t)
+	w := serve(NewSimple(), uploadRequest(t, "/thumbnail/simple?width=320&placeholder=blurhash,thumbhash&components=3x2"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	// A 3x2 BlurHash has a size flag, a maximum AC value, 4 characters of
+	// DC and 2 characters for each of the 5 AC components.
+	if hash := w.Header().Get("X-Thumbnail-BlurHash"); len(hash) != 16 {
+		t.Errorf("X-Thumbnail-BlurHash = %q, want 16 characters", hash)
+	}
+	if w.Header().Get("X-Thumbnail-ThumbHash") == "" {
+		t.Error("thumbnail is missing the X-Thumbnail-ThumbHash header")
+	}
+}
+
+func TestSimpleHandlerPalette(t *testing.T) {
+	useFakeBackend(t)
+	urls := []string{
+		"/thumbnail/simple?colors=3",
+		"/thumbnail/simple?colors=3&colorframes=4&format=png",
+	}
+
+	for _, url := range urls {
+		w := serve(NewSimple(), uploadRequest(t, url))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s status = %d, want %d: %s", url, w.Code, http.StatusOK, w.Body)
+		}
+		if p := w.Header().Get("X-Thumbnail-Palette"); !strings.HasPrefix(p, "#") {
+			t.Errorf("%s X-Thumbnail-Palette = %q, want a list of colors", url, p)
+		}
+	}
+}
+
+func TestOverlayParamsLogo(t *testing.T) {
+	useFakeBackend(t)
+	core.Opts.Logo = "/srv/logo.png"
+
+	opts := ffmpeg.DefaultOptions()
+	if err := overlayParams(url.Values{"logo": {"/etc/passwd"}}, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Logo != "" {
+		t.Errorf("Logo = %q, want the logo turned off", opts.Logo)
+	}
+
+	opts = ffmpeg.DefaultOptions()
+	if err := overlayParams(url.Values{"label": {"{time}"}}, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Logo != "/srv/logo.png" || opts.Label != "{time}" {
+		t.Errorf("Logo = %q and Label = %q, want the configured logo and {time}", opts.Logo, opts.Label)
+	}
+}
+
+func TestHandlerWithoutUpload(t *testing.T) {
+	useFakeBackend(t)
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	mw.Close()
+	r := httptest.NewRequest("POST", "/thumbnail/simple", body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+
+	w := serve(NewSimple(), r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandlerUnsupportedUpload(t *testing.T) {
+	useFakeBackend(t)
+	w := serve(NewSimple(), uploadFileRequest(t, "/thumbnail/simple", []byte("just some text")))
+
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnsupportedMediaType)
+	}
+}
+
+func TestIsSupportedMimeType(t *testing.T) {
+	tests := map[string]bool{
+		"video/mp4":                 true,
+		"video/x-matroska":          true,
+		"image/jpeg":                true,
+		"image/gif":                 true,
+		"image/heic":                true,
+		"application/octet-stream":  true,
+		"image/svg+xml":             false,
+		"text/plain; charset=utf-8": false,
+		"application/pdf":           false,
+	}
+
+	for mimeType, want := range tests {
+		if got := isSupportedMimeType(mimeType); got != want {
+			t.Errorf("isSupportedMimeType(%q) = %v, want %v", mimeType, got, want)
+		}
+	}
+}
+
+func TestSpriteHandlerJSON(t *testing.T) {
+	useFakeBackend(t)
+	w := serve(NewSprite(), uploadRequest(t, "/thumbnail/sprite?width=64&count=6&layout=3x&padding=2&output=json"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	manifest := ffmpeg.SpriteManifest{}
+	if err := json.NewDecoder(w.Body).Decode(&manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Width != 196 || manifest.Height != 74 || manifest.Columns != 3 || manifest.Rows != 2 {
+		t.Errorf("manifest = %dx%d with %dx%d tiles, want 196x74 with 3x2 tiles",
+			manifest.Width, manifest.Height, manifest.Columns, manifest.Rows)
+	}
+	if len(manifest.Frames) != 6 || manifest.Type != "image/jpeg" || manifest.Data == "" {
+		t.Fatalf("manifest has %d frames of type %q, want 6 embedded image/jpeg frames", len(manifest.Frames), manifest.Type)
+	}
+	last := manifest.Frames[5]
+	if last.Index != 6 || last.Time != 50 || last.X != 132 || last.Y != 38 {
+		t.Errorf("last frame = %+v, want index 6 at 50s and 132,38", last)
+	}
+}
+
+func TestSpriteHandlerZip(t *testing.T) {
+	useFakeBackend(t)
+	w := serve(NewSprite(), uploadRequest(t, "/thumbnail/sprite?count=6&output=zip"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != 2 || zr.File[1].Name != "thumbnail.json" {
+		t.Fatalf("zip has %d files, want thumbnail.jpg and thumbnail.json", len(zr.File))
+	}
+	fin, err := zr.File[1].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fin.Close()
+	manifest := ffmpeg.SpriteManifest{}
+	if err := json.NewDecoder(fin).Decode(&manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Image != "thumbnail.jpg" || manifest.Data != "" {
+		t.Errorf("manifest image = %q with %d bytes of data, want a reference to thumbnail.jpg", manifest.Image, len(manifest.Data))
+	}
+}
+
+func TestVTTHandler(t *testing.T) {
+	useFakeBackend(t)
+	w := serve(NewVTT(), uploadRequest(t, "/thumbnail/vtt?count=6"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	if fmt.Sprint(names) != "[thumbnail.jpg thumbnail.vtt]" {
+		t.Errorf("zip files = %v, want [thumbnail.jpg thumbnail.vtt]", names)
+	}
+}
+
+func TestFramesHandlerJSON(t *testing.T) {
+	useFakeBackend(t)
+	w := serve(NewFrames(), uploadRequest(t, "/thumbnail/frames?times=10,00:00:20.5&output=json&width=160"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	var frames []frameJSON
+	if err := json.Unmarshal(w.Body.Bytes(), &frames); err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 2 {
+		t.Fatalf("got %d frames, want 2", len(frames))
+	}
+	if frames[0].Time != 10 || frames[1].Time != 20.5 {
+		t.Errorf("frame times = %v and %v, want 10 and 20.5", frames[0].Time, frames[1].Time)
+	}
+	if frames[1].Timecode != "00:00:20.500" || frames[1].Type != "image/jpeg" || frames[1].Data == "" {
+		t.Errorf("frame = %+v, want a base64 encoded jpeg at 00:00:20.500", frames[1])
+	}
+}
+
+func TestFramesHandlerZip(t *testing.T) {
+	useFakeBackend(t)
+	w := serve(NewFrames(), uploadRequest(t, "/thumbnail/frames?count=3"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != 3 {
+		t.Errorf("zip has %d files, want 3", len(zr.File))
+	}
+}
+
+func TestFingerprintHandler(t *testing.T) {
+	useFakeBackend(t)
+	w := serve(NewFingerprint(), uploadRequest(t, "/fingerprint?hash=dhash&frames=4"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	fp := phash.Fingerprint{}
+	if err := json.Unmarshal(w.Body.Bytes(), &fp); err != nil {
+		t.Fatal(err)
+	}
+	if fp.Algorithm != phash.DHash || fp.Duration != 60 || len(fp.Frames) != 4 {
+		t.Errorf("fingerprint = %s of %vs with %d frames, want dhash of 60s with 4 frames", fp.Algorithm, fp.Duration, len(fp.Frames))
+	}
+}
+
+func TestHelpHandler(t *testing.T) {
+	w := serve(NewHelp(), httptest.NewRequest("GET", "/help", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	if !strings.Contains(w.Body.String(), "/thumbnail/waveform") || !strings.Contains(w.Body.String(), "{time}") {
+		t.Error("help page is missing the end points and query arguments")
+	}
+}
+
+func TestErrorStatusCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{paramError{errors.New("bad")}, http.StatusBadRequest},
+		{fmt.Errorf("%w webp", ffmpeg.ErrUnsupportedFormat), http.StatusBadRequest},
+		{fmt.Errorf("%w: late", ffmpeg.ErrTimestampOutOfRange), http.StatusBadRequest},
+		{ffmpeg.ErrNoVideoStream, http.StatusUnprocessableEntity},
+		{ffmpeg.ErrNoAudioStream, http.StatusUnprocessableEntity},
+		{&ffmpeg.Error{Stderr: "moov atom not found", Err: errors.New("exit status 1")}, http.StatusUnprocessableEntity},
+		{&ffmpeg.Error{Err: context.DeadlineExceeded}, http.StatusGatewayTimeout},
+		{&ffmpeg.Error{Err: context.Canceled}, http.StatusServiceUnavailable},
+		{errors.New("other"), http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		if got := errorStatusCode(test.err); got != test.want {
+			t.Errorf("errorStatusCode(%v) = %d, want %d", test.err, got, test.want)
+		}
+	}
+}
@@ -2,14 +2,72 @@ package handlers
 
 import (
 	"github.com/dulo-tech/service-thumbnails/core"
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
 	"html/template"
 	"net/http"
 )
 
 // HelpData stores template variables for the help page.
 type HelpData struct {
-	DefaultCount int
-	DefaultSkip  int
+	DefaultCount       int
+	DefaultHeight      int
+	DefaultMaxSize     int
+	DefaultFit         string
+	DefaultPadColor    string
+	DefaultSkip        string
+	DefaultLayout      string
+	DefaultPadding     int
+	DefaultMaxPadding  int
+	DefaultBackground  string
+	DefaultFormat      string
+	DefaultQuality     int
+	DefaultSelection   string
+	DefaultThreshold   float64
+	DefaultSeek        string
+	DefaultSmart       bool
+	DefaultCandidates  int
+	DefaultMaxCands    int
+	DefaultWindow      int
+	DefaultSegments    int
+	DefaultMaxSegments int
+	DefaultSegmentLen  float64
+	DefaultMaxLength   float64
+	DefaultFPS         int
+	DefaultMaxFPS      int
+	DefaultPalette     bool
+	DefaultCRF         int
+	DefaultTimes       string
+	DefaultFrames      int
+	DefaultMaxFrames   int
+	DefaultWaveWidth   int
+	DefaultWaveHeight  int
+	DefaultWaveColor   string
+	DefaultWaveBg      string
+	DefaultCoverArt    bool
+	DefaultLabel       string
+	DefaultLabelSize   int
+	DefaultMaxLabel    int
+	DefaultLabelColor  string
+	DefaultLabelBox    string
+	DefaultLabelPos    string
+	DefaultLogo        bool
+	DefaultLogoPos     string
+	DefaultLogoMargin  int
+	DefaultMaxLogo     int
+	DefaultLogoOpacity float64
+	DefaultTileWidth   int
+	DefaultColumns     int
+	DefaultMargin      int
+	DefaultMaxMargin   int
+	DefaultMaxTiles    int
+	DefaultMaxSprite   int
+	DefaultPlaceholder string
+	DefaultComponents  string
+	DefaultColors      int
+	DefaultColorFrames int
+	DefaultHash        string
+	DefaultHashFrames  int
+	DefaultMaxHashes   int
 }
 
 // HelpHandler is an HTTP handler for displaying a help page using HTML.
@@ -24,9 +82,70 @@ func NewHelp() *HelpHandler {
 
 // ServeHTTP implements http.Handler.ServeHTTP.
 func (h *HelpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	format, err := ffmpeg.ParseFormat(core.Opts.Format)
+	if err != nil {
+		format = ffmpeg.FormatJPEG
+	}
 	data := HelpData{
-		DefaultCount: core.Opts.Count,
-		DefaultSkip:  core.Opts.SkipSeconds,
+		DefaultCount:       core.Opts.Count,
+		DefaultHeight:      core.Opts.Height,
+		DefaultMaxSize:     ffmpeg.MaxSize,
+		DefaultFit:         core.Opts.Fit,
+		DefaultPadColor:    core.Opts.PadColor,
+		DefaultSkip:        core.Opts.SkipSeconds,
+		DefaultLayout:      core.Opts.Layout,
+		DefaultPadding:     core.Opts.Padding,
+		DefaultMaxPadding:  ffmpeg.MaxPadding,
+		DefaultBackground:  core.Opts.Background,
+		DefaultFormat:      string(format),
+		DefaultQuality:     core.Opts.Quality,
+		DefaultSelection:   core.Opts.Selection,
+		DefaultThreshold:   core.Opts.Threshold,
+		DefaultSeek:        core.Opts.Seek,
+		DefaultSmart:       core.Opts.Smart,
+		DefaultCandidates:  core.Opts.Candidates,
+		DefaultMaxCands:    DefaultMaxCandidates,
+		DefaultWindow:      core.Opts.SearchWindow,
+		DefaultSegments:    core.Opts.Segments,
+		DefaultMaxSegments: DefaultMaxSegments,
+		DefaultSegmentLen:  core.Opts.SegmentLen,
+		DefaultMaxLength:   DefaultMaxSegmentLength,
+		DefaultFPS:         core.Opts.FPS,
+		DefaultMaxFPS:      DefaultMaxFPS,
+		DefaultPalette:     core.Opts.Palette,
+		DefaultCRF:         core.Opts.CRF,
+		DefaultTimes:       core.Opts.Times,
+		DefaultFrames:      core.Opts.Frames,
+		DefaultMaxFrames:   DefaultMaxFrames,
+		DefaultWaveWidth:   ffmpeg.DefaultWaveformWidth,
+		DefaultWaveHeight:  ffmpeg.DefaultWaveformHeight,
+		DefaultWaveColor:   core.Opts.WaveColor,
+		DefaultWaveBg:      core.Opts.WaveBg,
+		DefaultCoverArt:    core.Opts.CoverArt,
+		DefaultLabel:       core.Opts.Label,
+		DefaultLabelSize:   core.Opts.LabelSize,
+		DefaultMaxLabel:    ffmpeg.MaxLabelSize,
+		DefaultLabelColor:  core.Opts.LabelColor,
+		DefaultLabelBox:    core.Opts.LabelBox,
+		DefaultLabelPos:    core.Opts.LabelPos,
+		DefaultLogo:        core.Opts.Logo != "",
+		DefaultLogoPos:     core.Opts.LogoPos,
+		DefaultLogoMargin:  core.Opts.LogoMargin,
+		DefaultMaxLogo:     ffmpeg.MaxLogoMargin,
+		DefaultLogoOpacity: core.Opts.LogoOpacity,
+		DefaultTileWidth:   ffmpeg.DefaultSheetTileWidth,
+		DefaultColumns:     core.Opts.Columns,
+		DefaultMargin:      core.Opts.Margin,
+		DefaultMaxMargin:   ffmpeg.MaxSheetMargin,
+		DefaultMaxTiles:    DefaultMaxTiles,
+		DefaultMaxSprite:   DefaultMaxSpriteFrames,
+		DefaultPlaceholder: core.Opts.Placeholder,
+		DefaultComponents:  core.Opts.Components,
+		DefaultColors:      core.Opts.Colors,
+		DefaultColorFrames: core.Opts.ColorFrames,
+		DefaultHash:        core.Opts.Hash,
+		DefaultHashFrames:  core.Opts.HashFrames,
+		DefaultMaxHashes:   DefaultMaxHashFrames,
 	}
 
 	t, err := template.New("help").Parse(helpTemplate)
@@ -54,23 +173,228 @@ const helpTemplate = `
             <li>
                 POST <a href="/thumbnail/simple">/thumbnail/simple</a>
                 <p>
-                    Generates a simple thumbnail from an uploaded video. A single video must be uploaded.
+                    Generates a simple thumbnail from an uploaded video or image. A single file must be uploaded.
+                    Images are turned upright using their EXIF orientation, and animated images use their first frame.
                     <br/>Possible query arguments:
                     <ul>
-                        <li>width - The width of the thumbnail. Defaults to the width of the video.</li>
-                        <li>skip - Skip this number of seconds into the video. Defaults to {{.DefaultSkip}}.</li>
+                        <li>width - The width of the thumbnail. Defaults to the width of the video. At most {{.DefaultMaxSize}}px.</li>
+                        <li>height - The height of the thumbnail. Keeps the aspect ratio of the video when 0. Defaults to {{.DefaultHeight}}. At most {{.DefaultMaxSize}}px.</li>
+                        <li>fit - How frames are sized when both width and height are given. One of "contain", "cover" or "stretch". Defaults to {{.DefaultFit}}.</li>
+                        <li>padcolor - The color of the padding added when fit is "contain". Defaults to {{.DefaultPadColor}}.</li>
+                        <li>skip - Skip to this position in the video. Given as seconds ("90.5"), a timecode ("00:01:30.5"),
+                            a percentage ("25%", encoded as "25%25") or a frame number ("1200f"). Defaults to {{.DefaultSkip}}.</li>
+                        <li>smart - Choose the best looking frame instead of the frame at skip when "1". The chosen time and
+                            its score are returned in the X-Thumbnail-Time and X-Thumbnail-Score headers. Defaults to {{.DefaultSmart}}.</li>
+                        <li>candidates - The number of frames considered when smart is "1", up to {{.DefaultMaxCands}}. Defaults to {{.DefaultCandidates}}.</li>
+                        <li>window - The number of seconds on either side of skip searched when smart is "1", no longer than the video.
+                            Use 0 to search the whole video. Defaults to {{.DefaultWindow}}.</li>
+                        <li>format - The image format of the thumbnail. One of "jpeg", "png", "webp", "avif" or "gif". Defaults to {{.DefaultFormat}}.</li>
+                        <li>quality - The image quality of the thumbnail, from 1 to 100. Defaults to {{.DefaultQuality}}.</li>
+                        <li>label - Text drawn on the thumbnail. "{{"{time}"}}" is replaced by the position of the frame. Defaults to "{{.DefaultLabel}}".</li>
+                        <li>labelsize - The font size of the label in pixels, up to {{.DefaultMaxLabel}}. Defaults to {{.DefaultLabelSize}}.</li>
+                        <li>labelcolor - The color of the label text. Defaults to {{.DefaultLabelColor}}.</li>
+                        <li>labelbox - The color of the box behind the label, with an optional opacity, eg "black@0.5". Empty for no box. Defaults to "{{.DefaultLabelBox}}".</li>
+                        <li>labelpos - The position of the label. One of "top-left", "top", "top-right", "center", "bottom-left", "bottom" or "bottom-right". Defaults to {{.DefaultLabelPos}}.</li>
+                        <li>logo - Draw the logo configured on the server when "1". Has no effect when no logo is configured. Defaults to {{.DefaultLogo}}.</li>
+                        <li>logopos - The position of the logo. Takes the same values as labelpos. Defaults to {{.DefaultLogoPos}}.</li>
+                        <li>logomargin - The number of pixels between the logo and the edges of the thumbnail, from 0 to {{.DefaultMaxLogo}}. Defaults to {{.DefaultLogoMargin}}.</li>
+                        <li>logoopacity - The opacity of the logo, from 0 to 1. Defaults to {{.DefaultLogoOpacity}}.</li>
+                        <li>placeholder - Placeholders created from the thumbnail, either "blurhash", "thumbhash" or both separated by a comma.
+                            They're returned in the X-Thumbnail-BlurHash and X-Thumbnail-ThumbHash headers. Only jpeg, png and gif
+                            thumbnails are supported. Defaults to "{{.DefaultPlaceholder}}".</li>
+                        <li>components - The number of horizontal and vertical BlurHash components in the format XxY, from 1 to 9 each.
+                            Defaults to {{.DefaultComponents}}.</li>
+                        <li>colors - The number of dominant colors found in the thumbnail, up to 16. They're returned in the X-Thumbnail-Palette
+                            header, most common first, as a comma separated list of hex colors and the share of the thumbnail they cover,
+                            eg "#1a1a1a 0.620, #f0c419 0.380". Use 0 for none. Defaults to {{.DefaultColors}}.</li>
+                        <li>colorframes - The number of frames spread through the video which the colors are found in instead of the thumbnail,
+                            up to {{.DefaultMaxFrames}}. Use 0 for the thumbnail, which must be a jpeg, png or gif. Defaults to {{.DefaultColorFrames}}.</li>
                     </ul>
                 </p>
             </li>
             <li>
                 POST <a href="/thumbnail/sprite">/thumbnail/sprite</a>
                 <p>
-                    Generates a sprite thumbnail from an uploaded video. A single video must be uploaded.
+                    Generates a sprite thumbnail from an uploaded video or animated image. A single file must be uploaded.
+                    Sprites of animated images are made from the frames of the image.
+                    <br/>Possible query arguments:
+                    <ul>
+                        <li>output - Either "image" for the sprite, "json" for a manifest of the sprite with the image embedded in base64,
+                            or "zip" for an archive holding the sprite (thumbnail.jpg) and a manifest which refers to it (thumbnail.json).
+                            The manifest gives the size of the sprite and its thumbnails, and the index, time and x/y offset of each
+                            thumbnail. Defaults to image.</li>
+                        <li>width - The width of the thumbnail. Defaults to 180px wide maintaining aspect ratio. At most {{.DefaultMaxSize}}px.</li>
+                        <li>height - The height of the thumbnail. Keeps the aspect ratio of the video when 0. Defaults to {{.DefaultHeight}}. At most {{.DefaultMaxSize}}px.</li>
+                        <li>fit - How frames are sized when both width and height are given. One of "contain", "cover" or "stretch". Defaults to {{.DefaultFit}}.</li>
+                        <li>padcolor - The color of the padding added when fit is "contain". Defaults to {{.DefaultPadColor}}.</li>
+                        <li>skip - Skip to this position in the video. Given as seconds ("90.5"), a timecode ("00:01:30.5"),
+                            a percentage ("25%", encoded as "25%25") or a frame number ("1200f"). Defaults to {{.DefaultSkip}}.</li>
+                        <li>count - The number of thumbnails to include in the sprite, up to {{.DefaultMaxSprite}}. Defaults to {{.DefaultCount}}.</li>
+                        <li>layout - How the thumbnails are arranged. Either "strip", "square" or a COLSxROWS grid. Defaults to {{.DefaultLayout}}.</li>
+                        <li>padding - The number of pixels between the thumbnails, from 0 to {{.DefaultMaxPadding}}. Defaults to {{.DefaultPadding}}.</li>
+                        <li>background - The color of the padding between the thumbnails. Defaults to {{.DefaultBackground}}.</li>
+                        <li>format - The image format of the sprite. One of "jpeg", "png", "webp", "avif" or "gif". Defaults to {{.DefaultFormat}}.</li>
+                        <li>quality - The image quality of the sprite, from 1 to 100. Defaults to {{.DefaultQuality}}.</li>
+                        <li>label - Text drawn on each frame of the sprite. "{{"{time}"}}" is replaced by the position of the frame. Defaults to "{{.DefaultLabel}}".</li>
+                        <li>labelsize - The font size of the label in pixels, up to {{.DefaultMaxLabel}}. Defaults to {{.DefaultLabelSize}}.</li>
+                        <li>labelcolor - The color of the label text. Defaults to {{.DefaultLabelColor}}.</li>
+                        <li>labelbox - The color of the box behind the label, with an optional opacity, eg "black@0.5". Empty for no box. Defaults to "{{.DefaultLabelBox}}".</li>
+                        <li>labelpos - The position of the label. One of "top-left", "top", "top-right", "center", "bottom-left", "bottom" or "bottom-right". Defaults to {{.DefaultLabelPos}}.</li>
+                        <li>select - How the thumbnails are chosen. Either "interval" or "scene". Defaults to {{.DefaultSelection}}.</li>
+                        <li>threshold - The minimum scene change score, from 0 to 1, when select is "scene". Defaults to {{.DefaultThreshold}}.</li>
+                        <li>seek - How frames are extracted. Either "fast", which seeks to each frame separately, or "accurate",
+                            which decodes the whole video. Defaults to {{.DefaultSeek}}.</li>
+                    </ul>
+                </p>
+            </li>
+            <li>
+                POST <a href="/thumbnail/vtt">/thumbnail/vtt</a>
+                <p>
+                    Generates a sprite thumbnail along with a WebVTT thumbnail track from an uploaded video. A single
+                    video must be uploaded. The sprite (thumbnail.jpg) and the track (thumbnail.vtt) are returned
+                    together in a zip archive.
+                    <br/>Possible query arguments:
+                    <ul>
+                        <li>width - The width of the thumbnail. Defaults to 180px wide maintaining aspect ratio. At most {{.DefaultMaxSize}}px.</li>
+                        <li>height - The height of the thumbnail. Keeps the aspect ratio of the video when 0. Defaults to {{.DefaultHeight}}. At most {{.DefaultMaxSize}}px.</li>
+                        <li>fit - How frames are sized when both width and height are given. One of "contain", "cover" or "stretch". Defaults to {{.DefaultFit}}.</li>
+                        <li>padcolor - The color of the padding added when fit is "contain". Defaults to {{.DefaultPadColor}}.</li>
+                        <li>skip - Skip to this position in the video. Given as seconds ("90.5"), a timecode ("00:01:30.5"),
+                            a percentage ("25%", encoded as "25%25") or a frame number ("1200f"). Defaults to {{.DefaultSkip}}.</li>
+                        <li>count - The number of thumbnails to include in the sprite, up to {{.DefaultMaxSprite}}. Defaults to {{.DefaultCount}}.</li>
+                        <li>layout - How the thumbnails are arranged. Either "strip", "square" or a COLSxROWS grid. Defaults to {{.DefaultLayout}}.</li>
+                        <li>padding - The number of pixels between the thumbnails, from 0 to {{.DefaultMaxPadding}}. Defaults to {{.DefaultPadding}}.</li>
+                        <li>background - The color of the padding between the thumbnails. Defaults to {{.DefaultBackground}}.</li>
+                        <li>format - The image format of the sprite. One of "jpeg", "png", "webp", "avif" or "gif". Defaults to {{.DefaultFormat}}.</li>
+                        <li>quality - The image quality of the sprite, from 1 to 100. Defaults to {{.DefaultQuality}}.</li>
+                        <li>label - Text drawn on each frame of the sprite. "{{"{time}"}}" is replaced by the position of the frame. Defaults to "{{.DefaultLabel}}".</li>
+                        <li>labelsize - The font size of the label in pixels, up to {{.DefaultMaxLabel}}. Defaults to {{.DefaultLabelSize}}.</li>
+                        <li>labelcolor - The color of the label text. Defaults to {{.DefaultLabelColor}}.</li>
+                        <li>labelbox - The color of the box behind the label, with an optional opacity, eg "black@0.5". Empty for no box. Defaults to "{{.DefaultLabelBox}}".</li>
+                        <li>labelpos - The position of the label. One of "top-left", "top", "top-right", "center", "bottom-left", "bottom" or "bottom-right". Defaults to {{.DefaultLabelPos}}.</li>
+                        <li>select - How the thumbnails are chosen. Either "interval" or "scene". Defaults to {{.DefaultSelection}}.</li>
+                        <li>threshold - The minimum scene change score, from 0 to 1, when select is "scene". Defaults to {{.DefaultThreshold}}.</li>
+                        <li>seek - How frames are extracted. Either "fast", which seeks to each frame separately, or "accurate",
+                            which decodes the whole video. Defaults to {{.DefaultSeek}}.</li>
+                    </ul>
+                </p>
+            </li>
+            <li>
+                POST <a href="/thumbnail/animated">/thumbnail/animated</a>
+                <p>
+                    Generates a short looping animation from evenly spaced excerpts of an uploaded video. A single
+                    video must be uploaded.
+                    <br/>Possible query arguments:
+                    <ul>
+                        <li>width - The width of the animation. Defaults to 320px wide maintaining aspect ratio. At most {{.DefaultMaxSize}}px.</li>
+                        <li>skip - Skip to this position in the video. Given as seconds ("90.5"), a timecode ("00:01:30.5"),
+                            a percentage ("25%", encoded as "25%25") or a frame number ("1200f"). Defaults to {{.DefaultSkip}}.</li>
+                        <li>segments - The number of excerpts in the animation, up to {{.DefaultMaxSegments}}. Defaults to {{.DefaultSegments}}.</li>
+                        <li>length - The length in seconds of each excerpt, up to {{.DefaultMaxLength}}. Defaults to {{.DefaultSegmentLen}}.</li>
+                        <li>fps - The frame rate of the animation, up to {{.DefaultMaxFPS}}. Defaults to {{.DefaultFPS}}.</li>
+                        <li>palette - Generate a palette from the frames of GIF animations when "1". Defaults to {{.DefaultPalette}}.</li>
+                        <li>format - The format of the animation. Either "gif" or "webp". Defaults to gif.</li>
+                        <li>quality - The image quality of WebP animations, from 1 to 100. Defaults to {{.DefaultQuality}}.</li>
+                    </ul>
+                </p>
+            </li>
+            <li>
+                POST <a href="/thumbnail/preview">/thumbnail/preview</a>
+                <p>
+                    Generates a short silent MP4 clip from evenly spaced excerpts of an uploaded video. A single
+                    video must be uploaded.
+                    <br/>Possible query arguments:
+                    <ul>
+                        <li>width - The width of the clip. Defaults to 480px wide maintaining aspect ratio. At most {{.DefaultMaxSize}}px.</li>
+                        <li>skip - Skip to this position in the video. Given as seconds ("90.5"), a timecode ("00:01:30.5"),
+                            a percentage ("25%", encoded as "25%25") or a frame number ("1200f"). Defaults to {{.DefaultSkip}}.</li>
+                        <li>segments - The number of excerpts in the clip, up to {{.DefaultMaxSegments}}. Defaults to {{.DefaultSegments}}.</li>
+                        <li>length - The length in seconds of each excerpt, up to {{.DefaultMaxLength}}. Defaults to {{.DefaultSegmentLen}}.</li>
+                        <li>crf - The constant rate factor of the clip, from 1 (best) to 51 (worst). Defaults to {{.DefaultCRF}}.</li>
+                    </ul>
+                </p>
+            </li>
+            <li>
+                POST <a href="/thumbnail/frames">/thumbnail/frames</a>
+                <p>
+                    Generates several stills from an uploaded video in a single pass. A single video must be uploaded.
+                    The stills are returned in a zip archive, or as a JSON array of objects with the index, time, timecode,
+                    type and base64 encoded data of each still. No more than {{.DefaultMaxFrames}} stills may be requested.
+                    <br/>Possible query arguments:
+                    <ul>
+                        <li>times - Comma separated list of positions in the video, in any of the formats accepted by skip.
+                            Defaults to "{{.DefaultTimes}}".</li>
+                        <li>count - The number of evenly spaced stills taken when times is empty. Defaults to {{.DefaultFrames}}.</li>
+                        <li>skip - Where the evenly spaced stills begin when times is empty. Defaults to {{.DefaultSkip}}.</li>
+                        <li>output - Either "zip" or "json". Defaults to zip.</li>
+                        <li>width - The width of the stills. Defaults to the width of the video. At most {{.DefaultMaxSize}}px.</li>
+                        <li>height - The height of the stills. Keeps the aspect ratio of the video when 0. Defaults to {{.DefaultHeight}}. At most {{.DefaultMaxSize}}px.</li>
+                        <li>fit - How frames are sized when both width and height are given. One of "contain", "cover" or "stretch". Defaults to {{.DefaultFit}}.</li>
+                        <li>padcolor - The color of the padding added when fit is "contain". Defaults to {{.DefaultPadColor}}.</li>
+                        <li>format - The image format of the stills. One of "jpeg", "png", "webp", "avif" or "gif". Defaults to {{.DefaultFormat}}.</li>
+                        <li>quality - The image quality of the stills, from 1 to 100. Defaults to {{.DefaultQuality}}.</li>
+                    </ul>
+                </p>
+            </li>
+            <li>
+                POST <a href="/thumbnail/waveform">/thumbnail/waveform</a>
+                <p>
+                    Generates an image of the audio waveform of an uploaded audio or video file. A single file must be uploaded.
+                    <br/>Possible query arguments:
+                    <ul>
+                        <li>width - The width of the image. Defaults to {{.DefaultWaveWidth}}. At most {{.DefaultMaxSize}}px.</li>
+                        <li>height - The height of the image. Uses {{.DefaultWaveHeight}} when 0. Defaults to {{.DefaultHeight}}. At most {{.DefaultMaxSize}}px.</li>
+                        <li>color - The color of the wave, by name or in hex, eg "#4a90d9". Defaults to {{.DefaultWaveColor}}.</li>
+                        <li>background - The color behind the wave. Defaults to {{.DefaultWaveBg}}.</li>
+                        <li>coverart - Return the picture embedded in the file, such as the cover of an album, instead of the
+                            waveform when "1". Files without a picture still get a waveform. Defaults to {{.DefaultCoverArt}}.</li>
+                        <li>fit - How the cover art is sized when both width and height are given. One of "contain", "cover" or "stretch". Defaults to {{.DefaultFit}}.</li>
+                        <li>padcolor - The color of the padding added to the cover art when fit is "contain". Defaults to {{.DefaultPadColor}}.</li>
+                        <li>format - The image format. One of "jpeg", "png", "webp", "avif" or "gif". Defaults to {{.DefaultFormat}}.</li>
+                        <li>quality - The image quality, from 1 to 100. Defaults to {{.DefaultQuality}}.</li>
+                    </ul>
+                </p>
+            </li>
+            <li>
+                POST <a href="/thumbnail/contactsheet">/thumbnail/contactsheet</a>
+                <p>
+                    Generates a printable contact sheet from an uploaded video or animated image. A single file must be uploaded.
+                    The sheet is a grid of evenly spaced frames, each captioned with its timecode, below a header showing
+                    the file name, duration, resolution, codecs and file size.
+                    <br/>Possible query arguments:
+                    <ul>
+                        <li>width - The width of each frame. Defaults to {{.DefaultTileWidth}}px wide maintaining aspect ratio. At most {{.DefaultMaxSize}}px.</li>
+                        <li>height - The height of each frame. Keeps the aspect ratio of the video when 0. Defaults to {{.DefaultHeight}}. At most {{.DefaultMaxSize}}px.</li>
+                        <li>fit - How frames are sized when both width and height are given. One of "contain", "cover" or "stretch". Defaults to {{.DefaultFit}}.</li>
+                        <li>padcolor - The color of the padding added when fit is "contain". Defaults to {{.DefaultPadColor}}.</li>
+                        <li>skip - Where the evenly spaced frames begin. Defaults to {{.DefaultSkip}}.</li>
+                        <li>count - The number of frames, up to {{.DefaultMaxTiles}}. Defaults to {{.DefaultCount}}.</li>
+                        <li>columns - The number of frames in each row. Defaults to {{.DefaultColumns}}.</li>
+                        <li>margin - The number of pixels around the frames and the header, from 0 to {{.DefaultMaxMargin}}. Defaults to {{.DefaultMargin}}.</li>
+                        <li>background - The color behind the frames and the header. Defaults to {{.DefaultBackground}}.</li>
+                        <li>format - The image format of the sheet. One of "jpeg", "png", "webp", "avif" or "gif". Defaults to {{.DefaultFormat}}.</li>
+                        <li>quality - The image quality of the sheet, from 1 to 100. Defaults to {{.DefaultQuality}}.</li>
+                        <li>label - The caption of each frame instead of its timecode. "{{"{time}"}}" is replaced by the position of the frame.</li>
+                        <li>labelsize - The font size of the captions and the header in pixels, up to {{.DefaultMaxLabel}}. Defaults to {{.DefaultLabelSize}}.</li>
+                        <li>labelcolor - The color of the captions and the header. Defaults to {{.DefaultLabelColor}}.</li>
+                        <li>labelbox - The color of the box behind the captions, with an optional opacity, eg "black@0.5". Empty for no box. Defaults to "{{.DefaultLabelBox}}".</li>
+                        <li>labelpos - The position of the captions. One of "top-left", "top", "top-right", "center", "bottom-left", "bottom" or "bottom-right". Defaults to {{.DefaultLabelPos}}.</li>
+                    </ul>
+                </p>
+            </li>
+            <li>
+                POST <a href="/fingerprint">/fingerprint</a>
+                <p>
+                    Returns the fingerprint of an uploaded video as JSON, which is used to find videos uploaded more than once
+                    under different encodings. A single file must be uploaded. The fingerprint holds the algorithm, the duration
+                    of the video, and the time and 64 bit perceptual hash, in hex, of frames spread evenly through the whole video.
+                    Two fingerprints made with the same algorithm and number of frames are compared by averaging the number
+                    of bits which differ between the hashes of each pair of frames. Near-duplicates differ by about 10 bits or less.
                     <br/>Possible query arguments:
                     <ul>
-                        <li>width - The width of the thumbnail. Defaults to 180px wide maintaining aspect ratio.</li>
-                        <li>skip - Skip this number of seconds into the video. Defaults to {{.DefaultSkip}}.</li>
-                        <li>count - The number of thumbnails to include in the sprite. Defaults to {{.DefaultCount}}.</li>
+                        <li>hash - The perceptual hash. Either "phash" or "dhash". Defaults to {{.DefaultHash}}.</li>
+                        <li>frames - The number of frames hashed, up to {{.DefaultMaxHashes}}. Fewer are hashed for clips without that many frames.
+                            Defaults to {{.DefaultHashFrames}}.</li>
                     </ul>
                 </p>
             </li>
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dulo-tech/service-thumbnails/core"
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+)
+
+// PreviewHandler is an HTTP handler for creating MP4 preview clips.
+type PreviewHandler struct {
+	Handler
+}
+
+// NewPreview creates and returns a new PreviewHandler instance.
+func NewPreview() *PreviewHandler {
+	return &PreviewHandler{}
+}
+
+// ServeHTTP implements http.Handler.ServeHTTP.
+func (h *PreviewHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	file := getFile(w, r)
+	if file == nil {
+		return
+	}
+
+	width := DefaultPreviewWidth
+	crf := core.Opts.CRF
+
+	query := r.URL.Query()
+	if w, ok := query["width"]; ok {
+		width = atoi(w[0])
+	}
+	if c, ok := query["crf"]; ok {
+		crf = atoi(c[0])
+	}
+	segments, length, err := segmentParams(query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := ffmpeg.CheckSize(width, 0); err != nil {
+		writeError(w, paramError{err})
+		return
+	}
+	if crf < 0 || crf > ffmpeg.MaxCRF {
+		writeError(w, paramError{fmt.Errorf("Invalid constant rate factor %d. Use 0 to %d.", crf, ffmpeg.MaxCRF)})
+		return
+	}
+	skip, err := skipParam(query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	temp := getTempFile()
+	opts := ffmpeg.DefaultOptions()
+	opts.Skip = skip
+	opts.Segments = segments
+	opts.SegmentLength = length
+	opts.CRF = crf
+
+	ff, err := newThumbnailer(file, opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = ff.CreatePreview(r.Context(), width, temp)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	numRequests++
+	w.Header().Set("Content-Disposition", "attachment; filename=preview.mp4")
+	w.Header().Set("Content-Type", "video/mp4")
+	writeFileToResponse(temp, w)
+}
@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"fmt"
 	"github.com/dulo-tech/service-thumbnails/core"
 	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+	"github.com/dulo-tech/service-thumbnails/palette"
+	"github.com/dulo-tech/service-thumbnails/placeholder"
 	"net/http"
+	"strconv"
 )
 
 // SimpleHandler is an HTTP handler for creating simple thumbnails.
@@ -24,30 +28,128 @@ func (h *SimpleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	width := DefaultSimpleWidth
-	skip := core.Opts.SkipSeconds
+	smart := core.Opts.Smart
+	candidates := core.Opts.Candidates
+	window := core.Opts.SearchWindow
 
 	query := r.URL.Query()
 	if w, ok := query["width"]; ok {
 		width = atoi(w[0])
 	}
-	if s, ok := query["skip"]; ok {
-		skip = atoi(s[0])
+	if s, ok := query["smart"]; ok {
+		smart = atob(s[0])
+	}
+	if c, ok := query["candidates"]; ok {
+		candidates = atoi(c[0])
+	}
+	if s, ok := query["window"]; ok {
+		window = atoi(s[0])
+	}
+	if candidates < 0 || candidates > DefaultMaxCandidates {
+		writeError(w, paramError{fmt.Errorf("Invalid number of candidates %d. Use 0 to %d.", candidates, DefaultMaxCandidates)})
+		return
+	}
+	if window < 0 {
+		writeError(w, paramError{fmt.Errorf("Invalid search window %d.", window)})
+		return
+	}
+	format, quality, err := formatParams(query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	height, fit, padColor, err := sizeParams(query, width)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	skip, err := skipParam(query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	placeholders, px, py, err := placeholderParams(query, format)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	colors, colorFrames, err := paletteParams(query, format)
+	if err != nil {
+		writeError(w, err)
+		return
 	}
 
 	temp := getTempFile()
-	ff := ffmpeg.New(file.Temp)
-	ff.SkipSeconds = skip
+	opts := ffmpeg.DefaultOptions()
+	opts.Skip = skip
+	opts.Format = format
+	opts.Quality = quality
+	opts.Height = height
+	opts.Fit = fit
+	opts.PadColor = padColor
+	opts.Candidates = candidates
+	opts.SearchWindow = window
+	opts.Workers = core.Opts.Workers
+	if err := overlayParams(query, &opts); err != nil {
+		writeError(w, err)
+		return
+	}
 
-	err := ff.CreateThumbnail(width, temp)
+	ff, err := newThumbnailer(file, opts)
 	if err != nil {
-		numErrors++
-		w.WriteHeader(500)
-		w.Write([]byte(err.Error()))
+		writeError(w, err)
 		return
 	}
 
+	if smart && window > 0 {
+		length, err := ff.Length(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if float64(window) > length {
+			writeError(w, paramError{fmt.Errorf("Invalid search window %d. The video is only %.0f seconds long.", window, length)})
+			return
+		}
+	}
+
+	if smart {
+		best, err := ff.CreateBestThumbnail(r.Context(), width, temp)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("X-Thumbnail-Time", strconv.FormatFloat(best.Time, 'f', 3, 64))
+		w.Header().Set("X-Thumbnail-Score", strconv.FormatFloat(best.Score.Total, 'f', 3, 64))
+	} else {
+		err := ff.CreateThumbnail(r.Context(), width, temp)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	p, err := placeholder.FromFile(temp, placeholders, px, py)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if p.BlurHash != "" {
+		w.Header().Set("X-Thumbnail-BlurHash", p.BlurHash)
+	}
+	if p.ThumbHash != "" {
+		w.Header().Set("X-Thumbnail-ThumbHash", p.ThumbHash)
+	}
+	if colors > 0 {
+		c, err := thumbnailPalette(r.Context(), file, temp, skip, colors, colorFrames)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("X-Thumbnail-Palette", palette.String(c))
+	}
+
 	numRequests++
-	w.Header().Set("Content-Disposition", "attachment; filename=thumbnail.jpg")
-	w.Header().Set("Content-Type", "image/jpeg")
+	setImageHeaders(w, format)
 	writeFileToResponse(temp, w)
 }
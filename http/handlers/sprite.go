@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
 	"github.com/dulo-tech/service-thumbnails/core"
 	"github.com/dulo-tech/service-thumbnails/ffmpeg"
-	"net/http"
 )
 
 // SpriteHandler is an HTTP handler for creating sprite thumbnails.
@@ -17,46 +22,174 @@ func NewSprite() *SpriteHandler {
 }
 
 // ServeHTTP implements http.Handler.ServeHTTP.
+// The "output" query argument chooses between the sprite image, a JSON manifest
+// with the image embedded in base64, or a zip archive holding the image and a
+// manifest which refers to it.
 func (h *SpriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	file := getFile(w, r)
 	if file == nil {
 		return
 	}
 
+	output := "image"
+	if o, ok := r.URL.Query()["output"]; ok {
+		output = o[0]
+	}
+	if output != "image" && output != "json" && output != "zip" {
+		writeError(w, paramError{fmt.Errorf("Invalid output %q.", output)})
+		return
+	}
+
+	ff, format, interval, width, err := spriteParams(r, file)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	temp := getTempFile()
+	sprite, err := ff.CreateThumbnailSprite(r.Context(), interval, width, temp)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	numRequests++
+	switch output {
+	case "json":
+		writeSpriteJSON(sprite, temp, format, w)
+	case "zip":
+		image := "thumbnail" + format.Ext()
+		tempManifest := getTempFile()
+		if err := sprite.WriteManifest(tempManifest, image, format); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Disposition", "attachment; filename=thumbnail.zip")
+		w.Header().Set("Content-Type", "application/zip")
+		writeZipToResponse([]string{temp, tempManifest}, []string{image, "thumbnail.json"}, w)
+	default:
+		setImageHeaders(w, format)
+		writeFileToResponse(temp, w)
+	}
+}
+
+// writeSpriteJSON writes the manifest of the sprite to the http response, with
+// the sprite image read from 'file' embedded in base64.
+func writeSpriteJSON(sprite ffmpeg.Sprite, file string, format ffmpeg.Format, w http.ResponseWriter) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		numErrors++
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(ffmpeg.SpriteManifest{
+		Type:   format.MimeType(),
+		Data:   base64.StdEncoding.EncodeToString(data),
+		Sprite: sprite,
+	})
+}
+
+// spriteParams creates a VideoThumbnailer for the uploaded file using the sprite
+// query arguments, and returns it along with the image format, frame interval
+// and width. A paramError is returned when a query argument is invalid.
+func spriteParams(r *http.Request, file *Upload) (ffmpeg.VideoThumbnailer, ffmpeg.Format, int, int, error) {
 	width := DefaultSpriteWidth
-	skip := core.Opts.SkipSeconds
 	count := core.Opts.Count
+	layout := core.Opts.Layout
+	padding := core.Opts.Padding
+	background := core.Opts.Background
+	selection := core.Opts.Selection
+	threshold := core.Opts.Threshold
+	seek := core.Opts.Seek
 
 	query := r.URL.Query()
 	if w, ok := query["width"]; ok {
 		width = atoi(w[0])
 	}
-	if s, ok := query["skip"]; ok {
-		skip = atoi(s[0])
-	}
 	if s, ok := query["count"]; ok {
 		count = atoi(s[0])
 	}
+	if l, ok := query["layout"]; ok {
+		layout = l[0]
+	}
+	if p, ok := query["padding"]; ok {
+		padding = atoi(p[0])
+	}
+	if b, ok := query["background"]; ok {
+		background = b[0]
+	}
+	if s, ok := query["select"]; ok {
+		selection = s[0]
+	}
+	if t, ok := query["threshold"]; ok {
+		threshold = atof(t[0])
+	}
+	if s, ok := query["seek"]; ok {
+		seek = s[0]
+	}
 
-	temp := getTempFile()
-	ff := ffmpeg.New(file.Temp)
-	ff.SkipSeconds = skip
+	if count < 1 || count > DefaultMaxSpriteFrames {
+		return nil, "", 0, 0, paramError{fmt.Errorf("The number of frames must be between 1 and %d.", DefaultMaxSpriteFrames)}
+	}
+	format, quality, err := formatParams(query)
+	if err != nil {
+		return nil, "", 0, 0, err
+	}
+	height, fit, padColor, err := sizeParams(query, width)
+	if err != nil {
+		return nil, "", 0, 0, err
+	}
+	skip, err := skipParam(query)
+	if err != nil {
+		return nil, "", 0, 0, err
+	}
+	l, err := ffmpeg.ParseLayout(layout)
+	if err != nil {
+		return nil, "", 0, 0, paramError{err}
+	}
+	if err := ffmpeg.CheckPadding(padding); err != nil {
+		return nil, "", 0, 0, paramError{err}
+	}
+	selection, err = ffmpeg.ParseSelection(selection)
+	if err != nil {
+		return nil, "", 0, 0, paramError{err}
+	}
+	if threshold < 0 || threshold > 1 {
+		return nil, "", 0, 0, paramError{fmt.Errorf("Invalid scene threshold %v.", threshold)}
+	}
+	seek, err = ffmpeg.ParseSeek(seek)
+	if err != nil {
+		return nil, "", 0, 0, paramError{err}
+	}
 
-	interval := int(ff.Length())
-	if interval > count {
-		interval = interval / count
+	opts := ffmpeg.DefaultOptions()
+	opts.Skip = skip
+	opts.Layout = l
+	opts.Padding = padding
+	opts.Background = background
+	opts.Format = format
+	opts.Quality = quality
+	opts.Height = height
+	opts.Fit = fit
+	opts.PadColor = padColor
+	opts.Selection = selection
+	opts.SceneThreshold = threshold
+	opts.Seek = seek
+	opts.Workers = core.Opts.Workers
+	if err := overlayParams(query, &opts); err != nil {
+		return nil, "", 0, 0, err
 	}
 
-	err := ff.CreateThumbnailSprite(interval, width, temp)
+	ff, err := newThumbnailer(file, opts)
 	if err != nil {
-		numErrors++
-		w.WriteHeader(500)
-		w.Write([]byte(err.Error()))
-		return
+		return nil, "", 0, 0, err
 	}
 
-	numRequests++
-	w.Header().Set("Content-Disposition", "attachment; filename=thumbnail.jpg")
-	w.Header().Set("Content-Type", "image/jpeg")
-	writeFileToResponse(temp, w)
+	interval, err := ff.SpriteInterval(r.Context(), count)
+	if err != nil {
+		return nil, "", 0, 0, err
+	}
+
+	return ff, format, interval, width, nil
 }
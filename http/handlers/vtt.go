@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+// VTTHandler is an HTTP handler for creating sprite thumbnails along with a
+// WebVTT thumbnail track.
+type VTTHandler struct {
+	Handler
+}
+
+// NewVTT creates and returns a new VTTHandler instance.
+func NewVTT() *VTTHandler {
+	return &VTTHandler{}
+}
+
+// ServeHTTP implements http.Handler.ServeHTTP.
+// The sprite and the track are returned together in a zip archive.
+func (h *VTTHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	file := getFile(w, r)
+	if file == nil {
+		return
+	}
+
+	ff, format, interval, width, err := spriteParams(r, file)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	temp := getTempFile()
+	tempVTT := getTempFile()
+	sprite := "thumbnail" + format.Ext()
+	err = ff.CreateThumbnailVTT(r.Context(), interval, width, temp, tempVTT, sprite)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	numRequests++
+	w.Header().Set("Content-Disposition", "attachment; filename=thumbnail.zip")
+	w.Header().Set("Content-Type", "application/zip")
+	writeZipToResponse([]string{temp, tempVTT}, []string{sprite, "thumbnail.vtt"}, w)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dulo-tech/service-thumbnails/core"
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+)
+
+// WaveformHandler is an HTTP handler for creating audio waveform images.
+type WaveformHandler struct {
+	Handler
+}
+
+// NewWaveform creates and returns a new WaveformHandler instance.
+func NewWaveform() *WaveformHandler {
+	return &WaveformHandler{}
+}
+
+// ServeHTTP implements http.Handler.ServeHTTP.
+func (h *WaveformHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	file := getFile(w, r)
+	if file == nil {
+		return
+	}
+
+	width := ffmpeg.DefaultWaveformWidth
+	color := core.Opts.WaveColor
+	background := core.Opts.WaveBg
+	coverArt := core.Opts.CoverArt
+
+	query := r.URL.Query()
+	if w, ok := query["width"]; ok {
+		width = atoi(w[0])
+	}
+	if c, ok := query["color"]; ok {
+		color = c[0]
+	}
+	if b, ok := query["background"]; ok {
+		background = b[0]
+	}
+	if c, ok := query["coverart"]; ok {
+		coverArt = atob(c[0])
+	}
+	if _, err := ffmpeg.ParseColor(color); err != nil {
+		writeError(w, paramError{err})
+		return
+	}
+	if _, err := ffmpeg.ParseColor(background); err != nil {
+		writeError(w, paramError{err})
+		return
+	}
+	format, quality, err := formatParams(query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	height, fit, padColor, err := sizeParams(query, width)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	temp := getTempFile()
+	opts := ffmpeg.DefaultOptions()
+	opts.Format = format
+	opts.Quality = quality
+	opts.Height = height
+	opts.Fit = fit
+	opts.PadColor = padColor
+	opts.WaveColor = color
+	opts.WaveBackground = background
+	opts.CoverArt = coverArt
+
+	ff, err := newThumbnailer(file, opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = ff.CreateWaveform(r.Context(), width, temp)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	numRequests++
+	setImageHeaders(w, format)
+	writeFileToResponse(temp, w)
+}
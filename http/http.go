@@ -13,6 +13,13 @@ func Go() {
 	router := mux.NewRouter()
 	router.Handle("/thumbnail/simple", handlers.NewSimple()).Methods("POST")
 	router.Handle("/thumbnail/sprite", handlers.NewSprite()).Methods("POST")
+	router.Handle("/thumbnail/vtt", handlers.NewVTT()).Methods("POST")
+	router.Handle("/thumbnail/animated", handlers.NewAnimated()).Methods("POST")
+	router.Handle("/thumbnail/preview", handlers.NewPreview()).Methods("POST")
+	router.Handle("/thumbnail/frames", handlers.NewFrames()).Methods("POST")
+	router.Handle("/thumbnail/waveform", handlers.NewWaveform()).Methods("POST")
+	router.Handle("/thumbnail/contactsheet", handlers.NewContactSheet()).Methods("POST")
+	router.Handle("/fingerprint", handlers.NewFingerprint()).Methods("POST")
 	router.Handle("/help", handlers.NewHelp()).Methods("GET")
 	router.Handle("/pulse", handlers.NewPulse()).Methods("GET")
 
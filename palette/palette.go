@@ -0,0 +1,245 @@
+// Package palette finds the dominant colors of thumbnails, which frontends
+// use as the background of the cards showing them.
+//
+// The colors are found by median cut: the sampled pixels are repeatedly split
+// in two along the color channel with the widest range, and each of the
+// resulting groups gives one color, weighted by the share of the pixels it
+// holds.
+package palette
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+)
+
+// Limits on the number of colors in a palette.
+const (
+	DefaultColors = 5
+	MaxColors     = 16
+)
+
+// FrameWidth is the width of the frames extracted by FromVideo. Small frames
+// are enough to find the dominant colors.
+const FrameWidth = 160
+
+// maxSamples is the largest number of pixels sampled from each image.
+const maxSamples = 10000
+
+// Color is one of the dominant colors of an image.
+type Color struct {
+	// Hex is the color in the format "#rrggbb".
+	Hex string `json:"color"`
+	// Weight is the share of the image covered by the color, from 0 to 1.
+	Weight float64 `json:"weight"`
+}
+
+// Extract returns up to 'n' dominant colors of the given images, most common
+// first. The images are treated as one, so the colors of several frames give
+// the colors of the whole video. Transparent pixels are ignored.
+func Extract(images []image.Image, n int) ([]Color, error) {
+	if n < 1 || n > MaxColors {
+		return nil, fmt.Errorf("Invalid number of colors %d. Use 1 to %d.", n, MaxColors)
+	}
+	pixels := [][3]uint8{}
+	for _, img := range images {
+		pixels = append(pixels, samples(img)...)
+	}
+	if len(pixels) == 0 {
+		return nil, errors.New("Cannot find the colors of an image without opaque pixels.")
+	}
+
+	boxes := [][][3]uint8{pixels}
+	for len(boxes) < n {
+		// The box to split is the one with the most pixels spread over the
+		// widest range, so large areas of similar colors are kept together.
+		split, channel, best := -1, 0, 0
+		for i, box := range boxes {
+			c, r := widestChannel(box)
+			if score := r * len(box); r > 0 && score > best {
+				split, channel, best = i, c, score
+			}
+		}
+		if split < 0 {
+			break
+		}
+
+		box := boxes[split]
+		sort.Slice(box, func(i, j int) bool {
+			return box[i][channel] < box[j][channel]
+		})
+		mid := splitPoint(box, channel)
+		boxes[split] = box[:mid]
+		boxes = append(boxes, box[mid:])
+	}
+
+	colors := make([]Color, len(boxes))
+	for i, box := range boxes {
+		colors[i] = Color{
+			Hex:    hex(average(box)),
+			Weight: math.Round(float64(len(box))/float64(len(pixels))*1000) / 1000,
+		}
+	}
+	sort.SliceStable(colors, func(i, j int) bool {
+		return colors[i].Weight > colors[j].Weight
+	})
+
+	return colors, nil
+}
+
+// FromFiles decodes the given JPEG, PNG or GIF images, and returns up to 'n'
+// of their dominant colors.
+func FromFiles(files []string, n int) ([]Color, error) {
+	images := make([]image.Image, len(files))
+	for i, file := range files {
+		fin, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		images[i], _, err = image.Decode(fin)
+		fin.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read image %q. Colors are found in jpeg, png or gif images: %w", file, err)
+		}
+	}
+
+	return Extract(images, n)
+}
+
+// FromVideo extracts 'count' frames spread evenly through the video, chosen
+// the same way as ffmpeg.FFmpeg.FrameTimes, and returns up to 'n' of their
+// dominant colors. The thumbnailer must write jpeg, png or gif images, and
+// should not draw overlays, which would add their colors to the palette.
+func FromVideo(ctx context.Context, f ffmpeg.VideoThumbnailer, count, n int) ([]Color, error) {
+	times, err := f.FrameTimes(ctx, count)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := ioutil.TempDir(ffmpeg.TempDirectory, "palette")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	stills, err := f.CreateFrames(ctx, times, FrameWidth, tmp+"/frame{index}.jpg")
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, len(stills))
+	for i, still := range stills {
+		files[i] = still.File
+	}
+
+	return FromFiles(files, n)
+}
+
+// String formats the colors as a comma separated list of colors and weights,
+// eg "#1a1a1a 0.620, #f0c419 0.380", which is used for http headers.
+func String(colors []Color) string {
+	parts := make([]string, len(colors))
+	for i, c := range colors {
+		parts[i] = fmt.Sprintf("%s %.3f", c.Hex, c.Weight)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// samples returns the opaque pixels of the image, skipping pixels in large
+// images so no more than about maxSamples are returned.
+func samples(img image.Image) [][3]uint8 {
+	b := img.Bounds()
+	step := 1
+	for (b.Dx()/step)*(b.Dy()/step) > maxSamples {
+		step++
+	}
+
+	pixels := make([][3]uint8, 0, (b.Dx()/step+1)*(b.Dy()/step+1))
+	for y := b.Min.Y; y < b.Max.Y; y += step {
+		for x := b.Min.X; x < b.Max.X; x += step {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A < 128 {
+				continue
+			}
+			pixels = append(pixels, [3]uint8{c.R, c.G, c.B})
+		}
+	}
+
+	return pixels
+}
+
+// widestChannel returns the color channel with the widest range of values in
+// the box, along with the range.
+func widestChannel(box [][3]uint8) (int, int) {
+	lo := [3]uint8{255, 255, 255}
+	hi := [3]uint8{}
+	for _, p := range box {
+		for c := 0; c < 3; c++ {
+			if p[c] < lo[c] {
+				lo[c] = p[c]
+			}
+			if p[c] > hi[c] {
+				hi[c] = p[c]
+			}
+		}
+	}
+
+	channel, width := 0, -1
+	for c := 0; c < 3; c++ {
+		if r := int(hi[c]) - int(lo[c]); r > width {
+			channel, width = c, r
+		}
+	}
+	return channel, width
+}
+
+// splitPoint returns the index the sorted box is split at, which is the
+// boundary between two values of the channel closest to the median. Pixels
+// with the same value are kept together, so each half has a narrower range.
+func splitPoint(box [][3]uint8, channel int) int {
+	median := len(box) / 2
+	v := box[median][channel]
+	lo := sort.Search(len(box), func(i int) bool {
+		return box[i][channel] >= v
+	})
+	hi := sort.Search(len(box), func(i int) bool {
+		return box[i][channel] > v
+	})
+
+	switch {
+	case lo == 0:
+		return hi
+	case hi == len(box):
+		return lo
+	case median-lo < hi-median:
+		return lo
+	}
+	return hi
+}
+
+// average returns the average color of the box.
+func average(box [][3]uint8) [3]uint8 {
+	var sum [3]int
+	for _, p := range box {
+		sum[0] += int(p[0])
+		sum[1] += int(p[1])
+		sum[2] += int(p[2])
+	}
+	n := len(box)
+	return [3]uint8{uint8((sum[0] + n/2) / n), uint8((sum[1] + n/2) / n), uint8((sum[2] + n/2) / n)}
+}
+
+// hex formats the color as "#rrggbb".
+func hex(c [3]uint8) string {
+	return fmt.Sprintf("#%02x%02x%02x", c[0], c[1], c[2])
+}
@@ -0,0 +1,81 @@
+package palette
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// splitImage returns an image with its left 'left' columns in 'a' and the
+// rest in 'b'.
+func splitImage(w, h, left int, a, b color.Color) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < left {
+				img.Set(x, y, a)
+			} else {
+				img.Set(x, y, b)
+			}
+		}
+	}
+	return img
+}
+
+func TestExtract(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	blue := color.NRGBA{0, 0, 255, 255}
+	img := splitImage(40, 10, 30, red, blue)
+
+	colors, err := Extract([]image.Image{img}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only two colors are found, since neither half can be split further.
+	if len(colors) != 2 {
+		t.Fatalf("Extract() = %v, want 2 colors", colors)
+	}
+	if colors[0] != (Color{"#ff0000", 0.75}) || colors[1] != (Color{"#0000ff", 0.25}) {
+		t.Errorf("Extract() = %v, want [{#ff0000 0.75} {#0000ff 0.25}]", colors)
+	}
+}
+
+func TestExtractIgnoresTransparentPixels(t *testing.T) {
+	img := splitImage(20, 20, 10, color.NRGBA{0, 255, 0, 255}, color.NRGBA{255, 255, 255, 0})
+
+	colors, err := Extract([]image.Image{img}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(colors) != 1 || colors[0] != (Color{"#00ff00", 1}) {
+		t.Errorf("Extract() = %v, want [{#00ff00 1}]", colors)
+	}
+
+	transparent := splitImage(4, 4, 4, color.NRGBA{}, color.NRGBA{})
+	if _, err := Extract([]image.Image{transparent}, 3); err == nil {
+		t.Error("Extract() error = nil, want an error for a transparent image")
+	}
+}
+
+func TestExtractInvalidCount(t *testing.T) {
+	img := splitImage(4, 4, 2, color.Black, color.White)
+	for _, n := range []int{0, MaxColors + 1} {
+		if _, err := Extract([]image.Image{img}, n); err == nil {
+			t.Errorf("Extract(%d) error = nil, want an error", n)
+		}
+	}
+}
+
+func TestSamples(t *testing.T) {
+	img := splitImage(1000, 1000, 500, color.Black, color.White)
+	if n := len(samples(img)); n > maxSamples {
+		t.Errorf("samples() returned %d pixels, want no more than %d", n, maxSamples)
+	}
+}
+
+func TestString(t *testing.T) {
+	got := String([]Color{{"#1a1a1a", 0.62}, {"#f0c419", 0.38}})
+	if want := "#1a1a1a 0.620, #f0c419 0.380"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,143 @@
+package phash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"io/ioutil"
+	"os"
+
+	"github.com/dulo-tech/service-thumbnails/ffmpeg"
+)
+
+// DefaultFrames is the number of frames hashed by FromVideo when 'count' is 0.
+const DefaultFrames = 16
+
+// DefaultMaxDistance is the largest distance between the fingerprints of
+// videos which are considered near-duplicates.
+const DefaultMaxDistance = 10
+
+// FrameWidth is the width of the frames extracted by FromVideo. The frames
+// are shrunk to 32x32 pixels or less when hashed.
+const FrameWidth = 128
+
+// Frame is the hash of a single frame of a video.
+type Frame struct {
+	// Time is the position of the frame in the video in seconds.
+	Time float64 `json:"time"`
+	Hash Hash    `json:"hash"`
+}
+
+// Fingerprint identifies how a video looks using the hashes of frames spread
+// evenly through it. Re-encoding, resizing or compressing the video changes
+// few bits of the hashes, so videos with a small Distance between their
+// fingerprints are near-duplicates.
+type Fingerprint struct {
+	Algorithm Algorithm `json:"algorithm"`
+	// Duration is the length of the video in seconds.
+	Duration float64 `json:"duration"`
+	Frames   []Frame `json:"frames"`
+}
+
+// FromVideo extracts 'count' frames spread evenly through the whole video, and
+// returns a fingerprint of the video using their hashes. DefaultFrames are used
+// when 'count' is 0, and fewer frames when the video doesn't have that many.
+// The skip option of the thumbnailer is ignored, so the frames of copies of a
+// video always line up. The thumbnailer must write jpeg images, and should not
+// draw overlays.
+func FromVideo(ctx context.Context, f ffmpeg.VideoThumbnailer, count int, algorithm Algorithm) (Fingerprint, error) {
+	fp := Fingerprint{Algorithm: algorithm}
+	m, err := f.Probe(ctx)
+	if err != nil {
+		return fp, err
+	}
+	length, err := f.Length(ctx)
+	if err != nil {
+		return fp, err
+	}
+	fp.Duration = length
+
+	times := frameTimes(length, m.FrameRate, count)
+	tmp, err := ioutil.TempDir(ffmpeg.TempDirectory, "phash")
+	if err != nil {
+		return fp, err
+	}
+	defer os.RemoveAll(tmp)
+
+	stills, err := f.CreateFrames(ctx, times, FrameWidth, tmp+"/frame{index}.jpg")
+	if err != nil {
+		return fp, err
+	}
+	for _, still := range stills {
+		img, err := decodeFile(still.File)
+		if err != nil {
+			return fp, err
+		}
+		fp.Frames = append(fp.Frames, Frame{Time: still.Time, Hash: algorithm.Sum(img)})
+	}
+
+	return fp, nil
+}
+
+// Distance returns the average distance between the hashes of the frames of
+// the fingerprints, from 0 for videos which look the same to 64. The
+// fingerprints must use the same algorithm and number of frames.
+func (fp Fingerprint) Distance(other Fingerprint) (float64, error) {
+	if fp.Algorithm != other.Algorithm {
+		return 0, fmt.Errorf("Cannot compare a %s fingerprint with a %s fingerprint.", fp.Algorithm, other.Algorithm)
+	}
+	if len(fp.Frames) != len(other.Frames) {
+		return 0, fmt.Errorf("Cannot compare fingerprints of %d and %d frames.", len(fp.Frames), len(other.Frames))
+	}
+	if len(fp.Frames) == 0 {
+		return 0, errors.New("Cannot compare fingerprints without frames.")
+	}
+
+	total := 0
+	for i, frame := range fp.Frames {
+		total += Distance(frame.Hash, other.Frames[i].Hash)
+	}
+	return float64(total) / float64(len(fp.Frames)), nil
+}
+
+// frameTimes returns the times of 'count' frames spread evenly through a video
+// 'length' seconds long. The count is lowered to the number of frames in the
+// video when it has fewer, so no two times fall on the same frame.
+func frameTimes(length, frameRate float64, count int) []ffmpeg.Timestamp {
+	if count < 1 {
+		count = DefaultFrames
+	}
+	if frameRate > 0 {
+		if frames := int(length * frameRate); frames < count {
+			count = frames
+		}
+	}
+	if count < 1 {
+		count = 1
+	}
+
+	share := length / float64(count)
+	times := make([]ffmpeg.Timestamp, count)
+	for i := range times {
+		times[i] = ffmpeg.Seconds((float64(i) + 0.5) * share)
+	}
+
+	return times
+}
+
+// decodeFile reads and decodes the given image file.
+func decodeFile(file string) (image.Image, error) {
+	fin, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fin.Close()
+
+	img, _, err := image.Decode(fin)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read frame %q: %w", file, err)
+	}
+	return img, nil
+}
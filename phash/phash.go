@@ -0,0 +1,196 @@
+// Package phash computes perceptual hashes of frames, and fingerprints of
+// whole videos built from them, which are used to find videos uploaded more
+// than once under different encodings.
+//
+// A perceptual hash is a 64 bit summary of how an image looks. Unlike a
+// checksum, images which look alike have hashes which differ in only a few
+// bits, so the number of differing bits, the Hamming distance, measures how
+// different two images are.
+package phash
+
+import (
+	"encoding/hex"
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+)
+
+// Algorithm is a perceptual hashing algorithm.
+type Algorithm string
+
+// Supported hashing algorithms.
+const (
+	// DHash compares the brightness of neighbouring pixels. It's quick, and
+	// robust to changes in color, brightness and compression.
+	DHash Algorithm = "dhash"
+	// PHash compares the low frequencies of the discrete cosine transform of
+	// the image. It's slower, but also robust to small changes such as
+	// watermarks and slight crops.
+	PHash Algorithm = "phash"
+)
+
+// Hash is a 64 bit perceptual hash.
+type Hash uint64
+
+// ParseAlgorithm converts a string into an Algorithm.
+// An empty string selects PHash.
+func ParseAlgorithm(s string) (Algorithm, error) {
+	switch Algorithm(strings.ToLower(strings.TrimSpace(s))) {
+	case "", PHash:
+		return PHash, nil
+	case DHash:
+		return DHash, nil
+	}
+
+	return "", fmt.Errorf("Invalid hash algorithm %q. Use 'phash' or 'dhash'.", s)
+}
+
+// Sum returns the hash of the image using the algorithm.
+func (a Algorithm) Sum(img image.Image) Hash {
+	if a == DHash {
+		return dhash(img)
+	}
+	return phash(img)
+}
+
+// Distance returns the number of bits which differ between the hashes, from
+// 0 for images which look the same to 64.
+func Distance(a, b Hash) int {
+	return bits.OnesCount64(uint64(a ^ b))
+}
+
+// String returns the hash as 16 hex digits.
+func (h Hash) String() string {
+	return fmt.Sprintf("%016x", uint64(h))
+}
+
+// MarshalText implements encoding.TextMarshaler, so hashes are written to
+// JSON as hex strings.
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (h *Hash) UnmarshalText(text []byte) error {
+	b, err := hex.DecodeString(string(text))
+	if err != nil || len(b) != 8 {
+		return fmt.Errorf("Invalid hash %q.", text)
+	}
+
+	*h = 0
+	for _, c := range b {
+		*h = *h<<8 | Hash(c)
+	}
+	return nil
+}
+
+// dhash returns the difference hash of the image. The image is shrunk to
+// 9x8 gray pixels, and each bit is set when a pixel is brighter than the
+// pixel to its left.
+func dhash(img image.Image) Hash {
+	pixels := grayscale(img, 9, 8)
+
+	var h Hash
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			h <<= 1
+			if pixels[y*9+x+1] > pixels[y*9+x] {
+				h |= 1
+			}
+		}
+	}
+	return h
+}
+
+// phash returns the DCT hash of the image. The image is shrunk to 32x32 gray
+// pixels, and each bit is set when one of the 8x8 lowest frequencies of its
+// discrete cosine transform is above the median of those frequencies.
+func phash(img image.Image) Hash {
+	const size, low = 32, 8
+	pixels := grayscale(img, size, size)
+
+	// cosines[u][x] is the DCT-II basis function for frequency u at x.
+	var cosines [low][size]float64
+	for u := 0; u < low; u++ {
+		for x := 0; x < size; x++ {
+			cosines[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * size))
+		}
+	}
+
+	// The rows are transformed first, then the columns of the result.
+	var rows [size][low]float64
+	for y := 0; y < size; y++ {
+		for u := 0; u < low; u++ {
+			for x := 0; x < size; x++ {
+				rows[y][u] += pixels[y*size+x] * cosines[u][x]
+			}
+		}
+	}
+	freqs := make([]float64, 0, low*low)
+	for v := 0; v < low; v++ {
+		for u := 0; u < low; u++ {
+			f := 0.0
+			for y := 0; y < size; y++ {
+				f += rows[y][u] * cosines[v][y]
+			}
+			freqs = append(freqs, f)
+		}
+	}
+
+	sorted := append([]float64(nil), freqs...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var h Hash
+	for _, f := range freqs {
+		h <<= 1
+		if f > median {
+			h |= 1
+		}
+	}
+	return h
+}
+
+// grayscale shrinks the image to 'w' by 'h' pixels, ignoring its aspect
+// ratio, and returns the brightness of each pixel from 0 to 1 in rows. Each
+// pixel is the average of the pixels it covers in the original image.
+func grayscale(img image.Image, w, h int) []float64 {
+	b := img.Bounds()
+	pixels := make([]float64, w*h)
+	if b.Empty() {
+		return pixels
+	}
+
+	sums := make([]float64, w*h)
+	counts := make([]int, w*h)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		py := (y - b.Min.Y) * h / b.Dy()
+		for x := b.Min.X; x < b.Max.X; x++ {
+			px := (x - b.Min.X) * w / b.Dx()
+			r, g, bl, _ := img.At(x, y).RGBA()
+			// ITU-R BT.601 luma, the same weights as image/color.GrayModel.
+			sums[py*w+px] += (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)) / 0xffff
+			counts[py*w+px]++
+		}
+	}
+
+	// Images smaller than the hash stretch their pixels over the gaps.
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			if counts[i] > 0 {
+				pixels[i] = sums[i] / float64(counts[i])
+				continue
+			}
+			sx := b.Min.X + x*b.Dx()/w
+			sy := b.Min.Y + y*b.Dy()/h
+			r, g, bl, _ := img.At(sx, sy).RGBA()
+			pixels[i] = (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)) / 0xffff
+		}
+	}
+
+	return pixels
+}
@@ -0,0 +1,153 @@
+package phash
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// gradient returns a 'w' by 'h' image which gets brighter from left to right,
+// with a darker band across its middle third.
+func gradient(w, h int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := x * 255 / w
+			if y > h/3 && y < 2*h/3 {
+				v /= 2
+			}
+			img.SetGray(x, y, color.Gray{uint8(v)})
+		}
+	}
+	return img
+}
+
+// scene returns a 'w' by 'h' image of smooth waves, which looks the same at
+// any size.
+func scene(w, h int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fx, fy := float64(x)/float64(w), float64(y)/float64(h)
+			v := 128 + 60*math.Sin(5*fx+1) + 50*math.Cos(7*fy*fx+2) + 15*math.Sin(11*fy)
+			img.SetGray(x, y, color.Gray{uint8(v)})
+		}
+	}
+	return img
+}
+
+// invert returns the negative of the image.
+func invert(img image.Image) image.Image {
+	b := img.Bounds()
+	out := image.NewGray(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			out.SetGray(x, y, color.Gray{255 - color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y})
+		}
+	}
+	return out
+}
+
+func TestDHash(t *testing.T) {
+	// Every pixel is brighter than the pixel to its left.
+	if h := DHash.Sum(gradient(90, 80)); h != 0xffffffffffffffff {
+		t.Errorf("DHash.Sum() = %s, want ffffffffffffffff", h)
+	}
+	if h := DHash.Sum(invert(gradient(90, 80))); h != 0 {
+		t.Errorf("DHash.Sum() of the inverted image = %s, want 0000000000000000", h)
+	}
+}
+
+func TestSumResized(t *testing.T) {
+	for _, a := range []Algorithm{DHash, PHash} {
+		large := a.Sum(scene(640, 360))
+		small := a.Sum(scene(160, 90))
+		if d := Distance(large, small); d > 4 {
+			t.Errorf("%s distance between sizes = %d, want no more than 4", a, d)
+		}
+		if d := Distance(large, a.Sum(invert(scene(640, 360)))); d < 32 {
+			t.Errorf("%s distance from the inverted image = %d, want at least 32", a, d)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	if d := Distance(0xff, 0x0f); d != 4 {
+		t.Errorf("Distance() = %d, want 4", d)
+	}
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	if a, err := ParseAlgorithm(""); err != nil || a != PHash {
+		t.Errorf("ParseAlgorithm(\"\") = %q, %v, want phash", a, err)
+	}
+	if a, err := ParseAlgorithm("DHash"); err != nil || a != DHash {
+		t.Errorf("ParseAlgorithm(DHash) = %q, %v, want dhash", a, err)
+	}
+	if _, err := ParseAlgorithm("md5"); err == nil {
+		t.Error("ParseAlgorithm(md5) error = nil, want an error")
+	}
+}
+
+func TestHashJSON(t *testing.T) {
+	data, err := json.Marshal(Frame{Time: 1.5, Hash: 0x0123456789abcdef})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"time":1.5,"hash":"0123456789abcdef"}`; string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+
+	frame := Frame{}
+	if err := json.Unmarshal(data, &frame); err != nil || frame.Hash != 0x0123456789abcdef {
+		t.Errorf("json.Unmarshal() = %s, %v, want 0123456789abcdef", frame.Hash, err)
+	}
+	if err := json.Unmarshal([]byte(`{"hash":"xyz"}`), &frame); err == nil {
+		t.Error("json.Unmarshal() error = nil, want an invalid hash error")
+	}
+}
+
+func TestFingerprintDistance(t *testing.T) {
+	a := Fingerprint{Algorithm: PHash, Frames: []Frame{{Hash: 0x0f}, {Hash: 0xff}}}
+	b := Fingerprint{Algorithm: PHash, Frames: []Frame{{Hash: 0x0f}, {Hash: 0x00}}}
+
+	if d, err := a.Distance(b); err != nil || d != 4 {
+		t.Errorf("Distance() = %v, %v, want 4", d, err)
+	}
+	if _, err := a.Distance(Fingerprint{Algorithm: DHash, Frames: b.Frames}); err == nil {
+		t.Error("Distance() error = nil, want an error for different algorithms")
+	}
+	if _, err := a.Distance(Fingerprint{Algorithm: PHash, Frames: b.Frames[:1]}); err == nil {
+		t.Error("Distance() error = nil, want an error for different numbers of frames")
+	}
+}
+
+func TestFrameTimes(t *testing.T) {
+	tests := []struct {
+		length, frameRate float64
+		count, want       int
+	}{
+		{60, 25, 16, 16},
+		{60, 25, 0, DefaultFrames},
+		{0.4, 25, 16, 10},
+		{0.5, 0, 16, 16},
+		{0.01, 25, 16, 1},
+	}
+
+	for _, test := range tests {
+		times := frameTimes(test.length, test.frameRate, test.count)
+		if len(times) != test.want {
+			t.Errorf("frameTimes(%v, %v, %d) = %d times, want %d", test.length, test.frameRate, test.count, len(times), test.want)
+			continue
+		}
+		for i := 1; i < len(times) && test.frameRate > 0; i++ {
+			prev, _ := times[i-1].Seconds(test.length, test.frameRate)
+			cur, _ := times[i].Seconds(test.length, test.frameRate)
+			if cur-prev < 1/test.frameRate-1e-9 {
+				t.Errorf("frameTimes(%v, %v, %d) times %v and %v fall on the same frame", test.length, test.frameRate, test.count, prev, cur)
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package placeholder
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+)
+
+// Default number of BlurHash components.
+const (
+	DefaultComponentsX = 4
+	DefaultComponentsY = 3
+)
+
+// base83 is the alphabet used by BlurHash strings.
+const base83 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// BlurHash returns the BlurHash of the image using 'x' horizontal and 'y'
+// vertical components, each between 1 and 9. More components keep more
+// detail at the cost of a longer string. Transparency is ignored.
+func BlurHash(img image.Image, x, y int) (string, error) {
+	if !validComponents(x, y) {
+		return "", fmt.Errorf("Invalid BlurHash components %dx%d. Each side must be between 1 and 9.", x, y)
+	}
+	small := shrink(img, maxSize)
+	w, h := small.Bounds().Dx(), small.Bounds().Dy()
+	if w == 0 || h == 0 {
+		return "", errors.New("Cannot create a BlurHash of an empty image.")
+	}
+
+	linear := make([][3]float64, w*h)
+	for py := 0; py < h; py++ {
+		for px := 0; px < w; px++ {
+			c := small.NRGBAAt(px, py)
+			linear[py*w+px] = [3]float64{srgbToLinear(c.R), srgbToLinear(c.G), srgbToLinear(c.B)}
+		}
+	}
+
+	// The factors are the weights of the cosine basis functions, starting
+	// with the average color.
+	factors := make([][3]float64, 0, x*y)
+	for j := 0; j < y; j++ {
+		for i := 0; i < x; i++ {
+			norm := 2.0
+			if i == 0 && j == 0 {
+				norm = 1
+			}
+			f := [3]float64{}
+			for py := 0; py < h; py++ {
+				fy := math.Cos(math.Pi * float64(j) * float64(py) / float64(h))
+				for px := 0; px < w; px++ {
+					basis := norm * fy * math.Cos(math.Pi*float64(i)*float64(px)/float64(w))
+					p := linear[py*w+px]
+					f[0] += basis * p[0]
+					f[1] += basis * p[1]
+					f[2] += basis * p[2]
+				}
+			}
+			scale := 1 / float64(w*h)
+			factors = append(factors, [3]float64{f[0] * scale, f[1] * scale, f[2] * scale})
+		}
+	}
+
+	hash := encode83((x-1)+(y-1)*9, 1)
+	maxValue := 1.0
+	if len(factors) > 1 {
+		actualMax := 0.0
+		for _, f := range factors[1:] {
+			actualMax = math.Max(actualMax, math.Max(math.Abs(f[0]), math.Max(math.Abs(f[1]), math.Abs(f[2]))))
+		}
+		quantisedMax := int(math.Max(0, math.Min(82, math.Floor(actualMax*166-0.5))))
+		maxValue = float64(quantisedMax+1) / 166
+		hash += encode83(quantisedMax, 1)
+	} else {
+		hash += encode83(0, 1)
+	}
+
+	dc := factors[0]
+	hash += encode83(linearToSRGB(dc[0])<<16+linearToSRGB(dc[1])<<8+linearToSRGB(dc[2]), 4)
+	for _, f := range factors[1:] {
+		hash += encode83(quantiseAC(f[0], maxValue)*19*19+quantiseAC(f[1], maxValue)*19+quantiseAC(f[2], maxValue), 2)
+	}
+
+	return hash, nil
+}
+
+// validComponents returns whether 'x' by 'y' BlurHash components are allowed.
+func validComponents(x, y int) bool {
+	return x >= 1 && x <= 9 && y >= 1 && y <= 9
+}
+
+// quantiseAC converts an AC factor into a number from 0 to 18.
+func quantiseAC(v, maxValue float64) int {
+	return int(math.Max(0, math.Min(18, math.Floor(signPow(v/maxValue, 0.5)*9+9.5))))
+}
+
+// encode83 encodes 'value' as 'length' base 83 digits.
+func encode83(value, length int) string {
+	buf := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		buf[i] = base83[value%83]
+		value /= 83
+	}
+	return string(buf)
+}
+
+// srgbToLinear converts an sRGB color channel into linear light, from 0 to 1.
+func srgbToLinear(c uint8) float64 {
+	v := float64(c) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB converts linear light, from 0 to 1, into an sRGB color channel.
+func linearToSRGB(v float64) int {
+	v = math.Max(0, math.Min(1, v))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+// signPow raises the magnitude of 'v' to 'exp', keeping its sign.
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
@@ -0,0 +1,160 @@
+// Package placeholder creates compact placeholders for thumbnails, which
+// frontends draw as a blurred preview while the thumbnail loads.
+//
+// Two encodings are supported. BlurHash (https://blurha.sh) is a short base 83
+// string, and ThumbHash (https://evanw.github.io/thumbhash/) is a slightly
+// longer base64 string which also keeps the aspect ratio and transparency of
+// the image. Both are computed in process from a decoded image.
+package placeholder
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Names of the placeholder encodings accepted by Parse.
+const (
+	NameBlurHash  = "blurhash"
+	NameThumbHash = "thumbhash"
+)
+
+// maxSize is the largest width or height of the image the placeholders are
+// computed from. Larger images are shrunk first, which is much faster and
+// makes no visible difference to the blurred result.
+const maxSize = 100
+
+// Placeholders holds the placeholders created by FromFile. Encodings which
+// were not requested are left empty.
+type Placeholders struct {
+	BlurHash  string `json:"blurhash,omitempty"`
+	ThumbHash string `json:"thumbhash,omitempty"`
+}
+
+// Parse converts a comma separated list of encoding names, eg
+// "blurhash,thumbhash", into a list of names. An empty string returns an
+// empty list.
+func Parse(s string) ([]string, error) {
+	names := []string{}
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "":
+			continue
+		case NameBlurHash, NameThumbHash:
+			names = append(names, name)
+		default:
+			return nil, fmt.Errorf("Invalid placeholder %q. Use 'blurhash' or 'thumbhash'.", name)
+		}
+	}
+
+	return names, nil
+}
+
+// ParseComponents converts the number of BlurHash components in the format
+// "XxY", eg "4x3", into the number of horizontal and vertical components.
+func ParseComponents(s string) (int, int, error) {
+	parts := strings.SplitN(strings.ToLower(strings.TrimSpace(s)), "x", 2)
+	if len(parts) == 2 {
+		x, errX := strconv.Atoi(parts[0])
+		y, errY := strconv.Atoi(parts[1])
+		if errX == nil && errY == nil && validComponents(x, y) {
+			return x, y, nil
+		}
+	}
+
+	return 0, 0, fmt.Errorf("Invalid BlurHash components %q. Use XxY with each side between 1 and 9, eg 4x3.", s)
+}
+
+// FromFile decodes the JPEG, PNG or GIF image 'file', and returns the
+// placeholders named in 'names'. The BlurHash uses 'x' by 'y' components.
+func FromFile(file string, names []string, x, y int) (Placeholders, error) {
+	p := Placeholders{}
+	if len(names) == 0 {
+		return p, nil
+	}
+	img, err := decodeFile(file)
+	if err != nil {
+		return p, err
+	}
+
+	for _, name := range names {
+		switch name {
+		case NameBlurHash:
+			if p.BlurHash, err = BlurHash(img, x, y); err != nil {
+				return p, err
+			}
+		case NameThumbHash:
+			p.ThumbHash = ThumbHash(img)
+		}
+	}
+
+	return p, nil
+}
+
+// decodeFile reads and decodes the given image file.
+func decodeFile(file string) (image.Image, error) {
+	fin, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fin.Close()
+
+	img, _, err := image.Decode(fin)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read image %q. Placeholders are made from jpeg, png or gif images: %w", file, err)
+	}
+
+	return img, nil
+}
+
+// shrink returns a copy of the image which fits inside 'max' by 'max' pixels,
+// keeping its aspect ratio. Each pixel is the average of the pixels it covers
+// in the original image. Images which already fit are only copied.
+func shrink(img image.Image, max int) *image.NRGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > max || h > max {
+		if w >= h {
+			w, h = max, h*max/w
+		} else {
+			w, h = w*max/h, max
+		}
+		if w < 1 {
+			w = 1
+		}
+		if h < 1 {
+			h = 1
+		}
+	}
+
+	out := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := b.Min.Y+y*b.Dy()/h, b.Min.Y+(y+1)*b.Dy()/h
+		for x := 0; x < w; x++ {
+			x0, x1 := b.Min.X+x*b.Dx()/w, b.Min.X+(x+1)*b.Dx()/w
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			r, g, bl, a = r/n, g/n, bl/n, a/n
+			// The colors returned by RGBA are premultiplied by alpha.
+			if a > 0 {
+				r, g, bl = r*0xffff/a, g*0xffff/a, bl*0xffff/a
+			}
+			out.SetNRGBA(x, y, color.NRGBA{uint8(r >> 8), uint8(g >> 8), uint8(bl >> 8), uint8(a >> 8)})
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,96 @@
+package placeholder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// solidImage returns a 'w' by 'h' image filled with 'c'.
+func solidImage(w, h int, c color.Color) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestBlurHash(t *testing.T) {
+	hash, err := BlurHash(solidImage(32, 24, color.Black), 4, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "L00000fQfQfQfQfQfQfQfQfQfQfQ"; hash != want {
+		t.Errorf("BlurHash() = %q, want %q", hash, want)
+	}
+
+	// A single component only holds the average color.
+	hash, err = BlurHash(solidImage(300, 200, color.White), 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "00TSUA"; hash != want {
+		t.Errorf("BlurHash() = %q, want %q", hash, want)
+	}
+
+	if _, err := BlurHash(solidImage(8, 8, color.White), 0, 3); err == nil {
+		t.Error("BlurHash() error = nil, want an invalid components error")
+	}
+}
+
+func TestThumbHash(t *testing.T) {
+	hash, err := base64.StdEncoding.DecodeString(ThumbHash(solidImage(100, 75, color.Gray{128})))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The header holds the average color, the scale of the AC factors, which
+	// is zero for a solid image, and a landscape flag. It's followed by 32 AC
+	// factors, two to a byte.
+	if want := []byte{32, 8, 2, 5, 128}; len(hash) != 21 || !bytes.Equal(hash[:5], want) {
+		t.Errorf("ThumbHash() = %v, want 21 bytes starting with %v", hash, want)
+	}
+}
+
+func TestShrink(t *testing.T) {
+	tests := []struct {
+		w, h         int
+		wantW, wantH int
+	}{
+		{640, 360, 100, 56},
+		{360, 640, 56, 100},
+		{80, 60, 80, 60},
+	}
+
+	for _, test := range tests {
+		b := shrink(solidImage(test.w, test.h, color.White), maxSize).Bounds()
+		if b.Dx() != test.wantW || b.Dy() != test.wantH {
+			t.Errorf("shrink(%dx%d) = %dx%d, want %dx%d", test.w, test.h, b.Dx(), b.Dy(), test.wantW, test.wantH)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	names, err := Parse("BlurHash, thumbhash")
+	if err != nil || len(names) != 2 || names[0] != NameBlurHash || names[1] != NameThumbHash {
+		t.Errorf("Parse() = %v, %v, want [blurhash thumbhash]", names, err)
+	}
+	if _, err := Parse("pixelate"); err == nil {
+		t.Error("Parse(pixelate) error = nil, want an error")
+	}
+}
+
+func TestParseComponents(t *testing.T) {
+	if x, y, err := ParseComponents("5x4"); err != nil || x != 5 || y != 4 {
+		t.Errorf("ParseComponents(5x4) = %d, %d, %v, want 5, 4", x, y, err)
+	}
+	for _, s := range []string{"", "4", "0x3", "4x10", "axb"} {
+		if _, _, err := ParseComponents(s); err == nil {
+			t.Errorf("ParseComponents(%q) error = nil, want an error", s)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package placeholder
+
+import (
+	"encoding/base64"
+	"image"
+	"math"
+)
+
+// ThumbHash returns the ThumbHash of the image, encoded in base64. Unlike a
+// BlurHash, the hash keeps the aspect ratio and transparency of the image.
+func ThumbHash(img image.Image) string {
+	return base64.StdEncoding.EncodeToString(thumbHash(shrink(img, maxSize)))
+}
+
+// thumbHash returns the binary ThumbHash of an image no larger than 100 by
+// 100 pixels.
+func thumbHash(img *image.NRGBA) []byte {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	n := w * h
+
+	// The average color, weighted by alpha.
+	var avgR, avgG, avgB, avgA float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := img.NRGBAAt(x, y)
+			alpha := float64(c.A) / 255
+			avgR += alpha / 255 * float64(c.R)
+			avgG += alpha / 255 * float64(c.G)
+			avgB += alpha / 255 * float64(c.B)
+			avgA += alpha
+		}
+	}
+	if avgA > 0 {
+		avgR /= avgA
+		avgG /= avgA
+		avgB /= avgA
+	}
+
+	hasAlpha := avgA < float64(n)
+	lLimit := 7
+	if hasAlpha {
+		// Fewer luminance bits are used when there's alpha.
+		lLimit = 5
+	}
+	longest := math.Max(float64(w), float64(h))
+	lx := maxInt(1, round(float64(lLimit*w)/longest))
+	ly := maxInt(1, round(float64(lLimit*h)/longest))
+
+	// The image is converted to luminance, yellow-blue, red-green and alpha
+	// channels, composited over the average color.
+	l := make([]float64, n)
+	p := make([]float64, n)
+	q := make([]float64, n)
+	a := make([]float64, n)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := img.NRGBAAt(x, y)
+			i := y*w + x
+			alpha := float64(c.A) / 255
+			r := avgR*(1-alpha) + alpha/255*float64(c.R)
+			g := avgG*(1-alpha) + alpha/255*float64(c.G)
+			b := avgB*(1-alpha) + alpha/255*float64(c.B)
+			l[i] = (r + g + b) / 3
+			p[i] = (r+g)/2 - b
+			q[i] = r - g
+			a[i] = alpha
+		}
+	}
+
+	lDC, lAC, lScale := encodeChannel(l, w, h, maxInt(3, lx), maxInt(3, ly))
+	pDC, pAC, pScale := encodeChannel(p, w, h, 3, 3)
+	qDC, qAC, qScale := encodeChannel(q, w, h, 3, 3)
+
+	isLandscape := w > h
+	header24 := round(63*lDC) | round(31.5+31.5*pDC)<<6 | round(31.5+31.5*qDC)<<12 | round(31*lScale)<<18
+	if hasAlpha {
+		header24 |= 1 << 23
+	}
+	header16 := lx
+	if isLandscape {
+		header16 = ly
+	}
+	header16 |= round(63*pScale)<<3 | round(63*qScale)<<9
+	if isLandscape {
+		header16 |= 1 << 15
+	}
+	hash := []byte{
+		byte(header24),
+		byte(header24 >> 8),
+		byte(header24 >> 16),
+		byte(header16),
+		byte(header16 >> 8),
+	}
+
+	channels := [][]float64{lAC, pAC, qAC}
+	if hasAlpha {
+		aDC, aAC, aScale := encodeChannel(a, w, h, 5, 5)
+		hash = append(hash, byte(round(15*aDC)|round(15*aScale)<<4))
+		channels = append(channels, aAC)
+	}
+
+	// The AC factors are packed two to a byte.
+	start := len(hash)
+	index := 0
+	for _, ac := range channels {
+		for _, f := range ac {
+			if start+index/2 >= len(hash) {
+				hash = append(hash, 0)
+			}
+			hash[start+index/2] |= byte(round(15*f) << uint((index&1)*4))
+			index++
+		}
+	}
+
+	return hash
+}
+
+// encodeChannel returns the DC and normalized AC factors of the discrete
+// cosine transform of a 'w' by 'h' channel, using up to 'nx' by 'ny' factors,
+// along with the scale the AC factors were normalized with.
+func encodeChannel(channel []float64, w, h, nx, ny int) (float64, []float64, float64) {
+	dc, scale := 0.0, 0.0
+	ac := []float64{}
+	fx := make([]float64, w)
+	for cy := 0; cy < ny; cy++ {
+		for cx := 0; cx*ny < nx*(ny-cy); cx++ {
+			for x := 0; x < w; x++ {
+				fx[x] = math.Cos(math.Pi / float64(w) * float64(cx) * (float64(x) + 0.5))
+			}
+			f := 0.0
+			for y := 0; y < h; y++ {
+				fy := math.Cos(math.Pi / float64(h) * float64(cy) * (float64(y) + 0.5))
+				for x := 0; x < w; x++ {
+					f += channel[x+y*w] * fx[x] * fy
+				}
+			}
+			f /= float64(w * h)
+			if cx > 0 || cy > 0 {
+				ac = append(ac, f)
+				scale = math.Max(scale, math.Abs(f))
+			} else {
+				dc = f
+			}
+		}
+	}
+	if scale > 0 {
+		for i := range ac {
+			ac[i] = 0.5 + 0.5/scale*ac[i]
+		}
+	}
+
+	return dc, ac, scale
+}
+
+// round rounds half up, the same way as the reference implementation.
+func round(v float64) int {
+	return int(math.Floor(v + 0.5))
+}
+
+// maxInt returns the larger of 'a' and 'b'.
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
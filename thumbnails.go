@@ -35,7 +35,7 @@ func main() {
 		if !inArrayString(opts.ThumbType, core.ValidThumbTypes) {
 			executeHelpTemplate("Invalid thumbnail type.")
 		}
-		cli.Go()
+		os.Exit(cli.Go())
 	case "http":
 		http.Go()
 	default:
@@ -85,6 +85,11 @@ func config() *core.Options {
 		"m",
 		core.Opts.Mode,
 		"Running mode, either 'cli' or 'http'. Defaults to 'cli'.")
+	flag.StringVar(
+		&core.Opts.Backend,
+		"backend",
+		core.Opts.Backend,
+		"The backend which creates thumbnails. Defaults to 'ffmpeg'. Builds with '-tags fake' also have 'fake'.")
 	flag.BoolVar(
 		&core.Opts.PrintHelp,
 		"help",
@@ -100,21 +105,251 @@ func config() *core.Options {
 		"q",
 		core.Opts.Quiet,
 		"Run in quiet mode.")
-	flag.IntVar(
+	flag.StringVar(
 		&core.Opts.SkipSeconds,
 		"s",
 		core.Opts.SkipSeconds,
-		"Skip this number of seconds into the video before thumbnailing.")
+		"Skip to this position in the video before thumbnailing. Given as seconds (90.5), a timecode (00:01:30.5), a percentage (25%) or a frame number (1200f).")
 	flag.IntVar(
 		&core.Opts.Count,
 		"c",
 		core.Opts.Count,
-		"Number of thumbs to generate in a sprite. 30 is the default.")
+		"Number of thumbs to generate in a sprite or contact sheet. 30 is the default.")
+	flag.StringVar(
+		&core.Opts.Layout,
+		"layout",
+		core.Opts.Layout,
+		"Sprite layout. Either 'strip', 'square' or a COLSxROWS grid.")
+	flag.IntVar(
+		&core.Opts.Padding,
+		"padding",
+		core.Opts.Padding,
+		"Number of pixels between the thumbs in a sprite, from 0 to 100.")
+	flag.StringVar(
+		&core.Opts.Background,
+		"bg",
+		core.Opts.Background,
+		"Color of the padding between the thumbs in a sprite.")
+	flag.StringVar(
+		&core.Opts.Format,
+		"f",
+		core.Opts.Format,
+		"Image format, one of 'jpeg', 'png', 'webp', 'avif' or 'gif'. Defaults to the output file extension.")
+	flag.IntVar(
+		&core.Opts.Quality,
+		"quality",
+		core.Opts.Quality,
+		"Image quality, from 1 to 100.")
+	flag.IntVar(
+		&core.Opts.Timeout,
+		"timeout",
+		core.Opts.Timeout,
+		"Maximum number of seconds each ffmpeg operation may run. 0 for no limit.")
+	flag.StringVar(
+		&core.Opts.Selection,
+		"select",
+		core.Opts.Selection,
+		"How sprite thumbs are chosen. Either 'interval' or 'scene'.")
+	flag.Float64Var(
+		&core.Opts.Threshold,
+		"threshold",
+		core.Opts.Threshold,
+		"Minimum scene change score, from 0 to 1, when using '-select scene'.")
+	flag.StringVar(
+		&core.Opts.Seek,
+		"seek",
+		core.Opts.Seek,
+		"How sprite frames are extracted. Either 'fast' or 'accurate'.")
+	flag.BoolVar(
+		&core.Opts.Manifest,
+		"manifest",
+		core.Opts.Manifest,
+		"Write a JSON manifest of the sprite next to the output file, using the .json extension.")
+	flag.IntVar(
+		&core.Opts.Workers,
+		"workers",
+		core.Opts.Workers,
+		"Number of frames extracted at the same time.")
+	flag.BoolVar(
+		&core.Opts.Smart,
+		"smart",
+		core.Opts.Smart,
+		"Choose the best looking frame for simple thumbs instead of the frame at -s.")
+	flag.IntVar(
+		&core.Opts.Candidates,
+		"candidates",
+		core.Opts.Candidates,
+		"Number of frames considered when using -smart.")
+	flag.IntVar(
+		&core.Opts.SearchWindow,
+		"window",
+		core.Opts.SearchWindow,
+		"Seconds on either side of -s searched when using -smart. 0 searches the whole video.")
+	flag.IntVar(
+		&core.Opts.Segments,
+		"segments",
+		core.Opts.Segments,
+		"Number of excerpts in an animated thumbnail or preview clip.")
+	flag.Float64Var(
+		&core.Opts.SegmentLen,
+		"seglen",
+		core.Opts.SegmentLen,
+		"Length in seconds of each excerpt in an animated thumbnail or preview clip.")
+	flag.IntVar(
+		&core.Opts.FPS,
+		"fps",
+		core.Opts.FPS,
+		"Frame rate of animated thumbnails.")
+	flag.BoolVar(
+		&core.Opts.Palette,
+		"palette",
+		core.Opts.Palette,
+		"Generate a palette for animated GIF thumbnails.")
+	flag.IntVar(
+		&core.Opts.CRF,
+		"crf",
+		core.Opts.CRF,
+		"Constant rate factor of preview clips, from 1 (best) to 51 (worst).")
+	flag.StringVar(
+		&core.Opts.Times,
+		"times",
+		core.Opts.Times,
+		"Comma separated list of positions in the video used by the frames type.")
+	flag.IntVar(
+		&core.Opts.Frames,
+		"frames",
+		core.Opts.Frames,
+		"Number of evenly spaced frames used by the frames type when -times is not given.")
+	flag.StringVar(
+		&core.Opts.WaveColor,
+		"wavecolor",
+		core.Opts.WaveColor,
+		"Color of the wave in waveform images.")
+	flag.StringVar(
+		&core.Opts.WaveBg,
+		"wavebg",
+		core.Opts.WaveBg,
+		"Color behind the wave in waveform images.")
+	flag.BoolVar(
+		&core.Opts.CoverArt,
+		"coverart",
+		core.Opts.CoverArt,
+		"Write the embedded cover art of audio files instead of their waveform.")
+	flag.StringVar(
+		&core.Opts.Label,
+		"label",
+		core.Opts.Label,
+		"Text drawn on simple thumbnails and sprite frames. '{time}' is replaced by the position of the frame.")
+	flag.StringVar(
+		&core.Opts.LabelFont,
+		"labelfont",
+		core.Opts.LabelFont,
+		"Font of the label, either a font file or a font name.")
+	flag.IntVar(
+		&core.Opts.LabelSize,
+		"labelsize",
+		core.Opts.LabelSize,
+		"Font size of the label in pixels, up to 200.")
+	flag.StringVar(
+		&core.Opts.LabelColor,
+		"labelcolor",
+		core.Opts.LabelColor,
+		"Color of the label text.")
+	flag.StringVar(
+		&core.Opts.LabelBox,
+		"labelbox",
+		core.Opts.LabelBox,
+		"Color of the box behind the label, with an optional opacity, eg 'black@0.5'. Empty for no box.")
+	flag.StringVar(
+		&core.Opts.LabelPos,
+		"labelpos",
+		core.Opts.LabelPos,
+		"Position of the label. One of 'top-left', 'top', 'top-right', 'center', 'bottom-left', 'bottom' or 'bottom-right'.")
+	flag.StringVar(
+		&core.Opts.Logo,
+		"logo",
+		core.Opts.Logo,
+		"Image file drawn over simple thumbnails.")
+	flag.StringVar(
+		&core.Opts.LogoPos,
+		"logopos",
+		core.Opts.LogoPos,
+		"Position of the logo. Takes the same values as -labelpos.")
+	flag.IntVar(
+		&core.Opts.LogoMargin,
+		"logomargin",
+		core.Opts.LogoMargin,
+		"Number of pixels between the logo and the edges of the thumbnail, from 0 to 100.")
+	flag.Float64Var(
+		&core.Opts.LogoOpacity,
+		"logoopacity",
+		core.Opts.LogoOpacity,
+		"Opacity of the logo, from 0 to 1.")
+	flag.IntVar(
+		&core.Opts.Columns,
+		"columns",
+		core.Opts.Columns,
+		"Number of frames in each row of a contact sheet.")
+	flag.IntVar(
+		&core.Opts.Margin,
+		"margin",
+		core.Opts.Margin,
+		"Number of pixels around the frames and header of a contact sheet, from 0 to 100.")
+	flag.StringVar(
+		&core.Opts.Placeholder,
+		"placeholder",
+		core.Opts.Placeholder,
+		"Comma separated placeholders written next to simple thumbnails. Either 'blurhash', 'thumbhash' or both.")
+	flag.StringVar(
+		&core.Opts.Components,
+		"components",
+		core.Opts.Components,
+		"Number of BlurHash components in the format XxY, from 1 to 9 each.")
+	flag.IntVar(
+		&core.Opts.Colors,
+		"colors",
+		core.Opts.Colors,
+		"Number of dominant colors written next to simple thumbnails, up to 16. Use 0 for none.")
+	flag.IntVar(
+		&core.Opts.ColorFrames,
+		"colorframes",
+		core.Opts.ColorFrames,
+		"Number of frames the dominant colors are found in. Use 0 for the thumbnail itself.")
+	flag.StringVar(
+		&core.Opts.Hash,
+		"hash",
+		core.Opts.Hash,
+		"Perceptual hash used by fingerprints. Either 'phash' or 'dhash'.")
+	flag.IntVar(
+		&core.Opts.HashFrames,
+		"hashframes",
+		core.Opts.HashFrames,
+		"Number of frames hashed in each fingerprint.")
+	flag.IntVar(
+		&core.Opts.MaxDistance,
+		"distance",
+		core.Opts.MaxDistance,
+		"Largest distance, from 0 to 64, between the fingerprints of near-duplicate videos.")
 	flag.IntVar(
 		&core.Opts.Width,
 		"w",
 		core.Opts.Width,
-		"The thumbnail width. Overrides the built in defaults.")
+		"The thumbnail width, up to 8192. Overrides the built in defaults.")
+	flag.IntVar(
+		&core.Opts.Height,
+		"height",
+		core.Opts.Height,
+		"The thumbnail height, up to 8192. Keeps the video aspect ratio when 0.")
+	flag.StringVar(
+		&core.Opts.Fit,
+		"fit",
+		core.Opts.Fit,
+		"How frames fit the width and height. One of 'contain', 'cover' or 'stretch'.")
+	flag.StringVar(
+		&core.Opts.PadColor,
+		"padcolor",
+		core.Opts.PadColor,
+		"Color of the padding added by '-fit contain'.")
 	flag.StringVar(
 		&core.Opts.ThumbType,
 		"t",
@@ -201,6 +436,12 @@ func readConfigFile(file string, opts *core.Options) {
 					if !field.OverflowInt(x) {
 						field.SetInt(x)
 					}
+				} else if field.Kind() == reflect.Float64 {
+					x, err := strconv.ParseFloat(parts[1], 64)
+					if err != nil {
+						panic(fmt.Sprintf("Invalid configuration at line %d. Expecting number: %q", line, text))
+					}
+					field.SetFloat(x)
 				} else if field.Kind() == reflect.String {
 					field.SetString(parts[1])
 				} else if field.Kind() == reflect.Bool {
@@ -267,24 +508,54 @@ OPTIONS:
 
 {{.Flags}}
 CLI USAGE:
-	thumbnailer -t <sprite|simple> -i <video> -o <image>
-
-	<sprite|simple> determines the type of thumbnail being generated. Either
-	a sprite or a simple thumbnail. Simple is the default when not specified.
+	thumbnailer -t <type> -i <video> -o <image>
+
+	<type> determines the type of thumbnail being generated. One of 'sprite',
+	'simple', 'vtt', 'animated', 'preview', 'frames', 'waveform',
+	'contactsheet', 'fingerprint' or 'compare'. Simple is the default when not
+	specified. The vtt type generates a sprite along with a WebVTT thumbnail
+	track, which is written next to the <image> using the .vtt file extension.
+	The animated type generates a short looping GIF or WebP animation, and the
+	preview type generates a short silent MP4 clip. The frames type writes
+	several stills to separate images. The waveform type draws the waveform of
+	an audio file. The contactsheet type generates a printable grid of
+	captioned frames below a header describing the video. The fingerprint type
+	writes the perceptual hashes of frames spread through the video to a JSON
+	file, and the compare type compares the fingerprints of every pair of
+	videos, prints the near-duplicates and writes the distance between each
+	pair to the JSON file <image>.
 
 	<video> is one or more source videos. Separate multiple videos with commas.
+	A directory is replaced by the files in it.
 
 	<image> may contain the place holders {name} and {type} which correspond
 	to the name of the source video (without file extension) and the type of
-	of thumbnail. One of 'sprite' or 'simple'. The <image> may also contain
-	the verb %d which will be replaced with the file number. See the fmt package
-	for more information on verbs.
+	thumbnail. The <image> may also contain the verb %d which will be replaced
+	with the file number. See the fmt package for more information on verbs.
+	For the frames type the <image> may also contain {index} and {time}, which
+	are replaced by the number of the still and its position in seconds. The
+	compare type writes a single file, so its <image> may only contain {type}.
 
 CLI EXAMPLES:
 
 	thumbnailer -t sprite -i source.mp4 -o thumb.jpg
 	thumbnailer -i source1.mp4,source2.mp4 -o out%02d.jpg
 	thumbnailer -t sprite -i source.mp4 -o thumb{name}{type}.jpg
+	thumbnailer -t vtt -i source.mp4 -o thumb.jpg
+	thumbnailer -t preview -segments 8 -crf 30 -i source.mp4 -o teaser.mp4
+	thumbnailer -t animated -segments 6 -seglen 1.5 -w 320 -i source.mp4 -o thumb.gif
+	thumbnailer -t sprite -layout 5x -padding 2 -bg white -i source.mp4 -o thumb.jpg
+	thumbnailer -w 320 -height 180 -fit cover -i source.mp4 -o thumb.jpg
+	thumbnailer -f webp -quality 80 -i source.mp4 -o thumb.webp
+	thumbnailer -smart -s 30 -window 10 -i source.mp4 -o thumb.jpg
+	thumbnailer -t sprite -select scene -threshold 0.4 -i source.mp4 -o thumb.jpg
+	thumbnailer -t sprite -seek fast -workers 8 -i source.mp4 -o thumb.jpg
+	thumbnailer -t sprite -manifest -layout 10x -i source.mp4 -o thumb.jpg
+	thumbnailer -t frames -times 10,25%,00:02:30 -i source.mp4 -o poster{index}.jpg
+	thumbnailer -t frames -frames 4 -i source.mp4 -o poster-{time}.jpg
+	thumbnailer -t contactsheet -c 24 -columns 4 -w 320 -i source.mp4 -o sheet.png
+	thumbnailer -t fingerprint -hash dhash -i source.mp4 -o {name}.json
+	thumbnailer -t compare -distance 8 -i videos/ -o duplicates.json
 
 HTTP USAGE:
 	thumbnailer -m http -h <host> -p <port>
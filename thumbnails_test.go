@@ -0,0 +1,84 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/dulo-tech/service-thumbnails/core"
+)
+
+// writeConfigFile writes a configuration file with the given contents, and
+// returns its path.
+func writeConfigFile(t *testing.T, contents string) string {
+	fout, err := ioutil.TempFile("", "thumbnails.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Remove(fout.Name())
+	})
+	fout.WriteString(contents)
+	fout.Close()
+
+	return fout.Name()
+}
+
+func TestReadConfigFile(t *testing.T) {
+	file := writeConfigFile(t, `
+# Comments and blank lines are skipped.
+
+Mode = http
+Port=3366
+Threshold=0.45
+Smart=yes
+Palette=false
+SkipSeconds=25%
+Times=10,00:00:30
+Unknown=ignored
+`)
+	opts := &core.Options{Palette: true}
+	readConfigFile(file, opts)
+
+	if opts.Mode != "http" {
+		t.Errorf("Mode = %q, want http", opts.Mode)
+	}
+	if opts.Port != 3366 {
+		t.Errorf("Port = %d, want 3366", opts.Port)
+	}
+	if opts.Threshold != 0.45 {
+		t.Errorf("Threshold = %v, want 0.45", opts.Threshold)
+	}
+	if !opts.Smart {
+		t.Error("Smart = false, want true")
+	}
+	if opts.Palette {
+		t.Error("Palette = true, want false")
+	}
+	if opts.SkipSeconds != "25%" {
+		t.Errorf("SkipSeconds = %q, want 25%%", opts.SkipSeconds)
+	}
+	if opts.Times != "10,00:00:30" {
+		t.Errorf("Times = %q, want 10,00:00:30", opts.Times)
+	}
+}
+
+func TestReadConfigFileInvalid(t *testing.T) {
+	tests := []string{
+		"Port=eighty",
+		"Threshold=high",
+		"Mode",
+	}
+
+	for _, contents := range tests {
+		file := writeConfigFile(t, contents)
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("readConfigFile(%q) did not panic", contents)
+				}
+			}()
+			readConfigFile(file, &core.Options{})
+		}()
+	}
+}